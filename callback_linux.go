@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build linux && (amd64 || arm64)
+
+package purego
+
+import (
+	"encoding/binary"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// callbackasm1ABI0 is the address of callbackasm1 which the trampolines jump to.
+var callbackasm1ABI0 uintptr
+
+func callbackTrampolinesPerPage() int {
+	return syscall.Getpagesize() / callbackTrampolineSize
+}
+
+// newCallbackTrampolines maps a page of trampolines for the callbacks starting at index first.
+// The page is never unmapped since freed callbacks are reused.
+func newCallbackTrampolines(first int) (uintptr, error) {
+	mem, err := syscall.Mmap(-1, 0, syscall.Getpagesize(), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE|syscall.MAP_ANON)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < callbackTrampolinesPerPage(); i++ {
+		writeCallbackTrampoline(mem[i*callbackTrampolineSize:(i+1)*callbackTrampolineSize], first+i)
+	}
+	// the kernel makes the instruction cache coherent when the page becomes executable
+	if err := syscall.Mprotect(mem, syscall.PROT_READ|syscall.PROT_EXEC); err != nil {
+		_ = syscall.Munmap(mem)
+		return 0, err
+	}
+	return uintptr(unsafe.Pointer(&mem[0])), nil
+}
+
+// writeCallbackTrampoline writes the machine code that enters callbackasm1 like the
+// entry i of callbackasm would.
+func writeCallbackTrampoline(b []byte, i int) {
+	switch runtime.GOARCH {
+	case "amd64":
+		// callbackasm1 computes the index from the return address pushed by the CALL
+		// in callbackasm so push the address that the entry i would have pushed.
+		b[0], b[1] = 0x49, 0xbb // MOVQ $ret, R11
+		binary.LittleEndian.PutUint64(b[2:], uint64(callbackasmAddr(i+1)))
+		b[10], b[11] = 0x41, 0x53 // PUSHQ R11
+		b[12], b[13] = 0x49, 0xbb // MOVQ $callbackasm1, R11
+		binary.LittleEndian.PutUint64(b[14:], uint64(callbackasm1ABI0))
+		b[22], b[23], b[24] = 0x41, 0xff, 0xe3 // JMP R11
+		for j := 25; j < len(b); j++ {
+			b[j] = 0xcc // INT3
+		}
+	case "arm64":
+		// callbackasm1 takes the index in R12
+		binary.LittleEndian.PutUint32(b[0:], 0x5800008c)  // LDR 16(PC), R12
+		binary.LittleEndian.PutUint32(b[4:], 0x580000b0)  // LDR 24(PC), R16
+		binary.LittleEndian.PutUint32(b[8:], 0xd61f0200)  // BR R16
+		binary.LittleEndian.PutUint32(b[12:], 0xd503201f) // NOP
+		binary.LittleEndian.PutUint64(b[16:], uint64(i))
+		binary.LittleEndian.PutUint64(b[24:], uint64(callbackasm1ABI0))
+	}
+}
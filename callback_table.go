@@ -0,0 +1,16 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build darwin || freebsd || (linux && loong64) || netbsd
+
+package purego
+
+import "errors"
+
+func callbackTrampolinesPerPage() int {
+	return 0
+}
+
+func newCallbackTrampolines(first int) (uintptr, error) {
+	return 0, errors.New("callbacks can't be created at runtime on this platform")
+}
@@ -9,10 +9,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 	"unsafe"
 
 	"github.com/ebitengine/purego"
+	"github.com/ebitengine/purego/internal/load"
 )
 
 // TestCallGoFromSharedLib is a test that checks for stack corruption on arm64
@@ -148,6 +154,465 @@ func TestNewCallbackFloat32AndFloat64(t *testing.T) {
 	}
 }
 
+func TestNewCallbackManyArguments(t *testing.T) {
+	// This tests that a callback can read many arguments from the stack
+	const (
+		expectCBTotal    = 595
+		expectedCBTotalF = float64(24)
+	)
+	var cbTotal int
+	var cbTotalF float64
+	imp := purego.NewCallback(func(a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14, a15, a16, a17, a18, a19, a20,
+		a21, a22, a23, a24, a25, a26, a27, a28, a29, a30, a31, a32, a33, a34 int,
+		f1, f2, f3, f4, f5, f6, f7, f8 float64,
+	) int {
+		cbTotal = a1 + a2 + a3 + a4 + a5 + a6 + a7 + a8 + a9 + a10 + a11 + a12 + a13 + a14 + a15 + a16 + a17 + a18 + a19 + a20 +
+			a21 + a22 + a23 + a24 + a25 + a26 + a27 + a28 + a29 + a30 + a31 + a32 + a33 + a34
+		cbTotalF = f1 + f2 + f3 + f4 + f5 + f6 + f7 + f8
+		return a34
+	})
+	var fun func(a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14, a15, a16, a17, a18, a19, a20,
+		a21, a22, a23, a24, a25, a26, a27, a28, a29, a30, a31, a32, a33, a34 int,
+		f1, f2, f3, f4, f5, f6, f7, f8 float64,
+	) int
+	purego.RegisterFunc(&fun, imp)
+	ret := fun(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20,
+		21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34,
+		1, 2, 3, 4, 5, 6, 1, 2)
+	if ret != 34 {
+		t.Errorf("callback returned %d wanted %d", ret, 34)
+	}
+	if cbTotal != expectCBTotal {
+		t.Errorf("cbTotal not correct got %d but wanted %d", cbTotal, expectCBTotal)
+	}
+	if cbTotalF != expectedCBTotalF {
+		t.Errorf("cbTotalF not correct got %f but wanted %f", cbTotalF, expectedCBTotalF)
+	}
+}
+
+func TestNewCallbackHandle(t *testing.T) {
+	type state struct{ calls int }
+	s := &state{}
+	h := purego.NewHandle(s)
+	defer h.Delete()
+
+	cb := purego.NewCallback(func(n int, userdata purego.Handle) int {
+		st := userdata.Value().(*state)
+		st.calls += n
+		return st.calls
+	})
+	var fn func(n int, userdata purego.Handle) int
+	purego.RegisterFunc(&fn, cb)
+	fn(1, h)
+	if ret := fn(2, h); ret != 3 || s.calls != 3 {
+		t.Errorf("callback returned %d with %d calls wanted 3", ret, s.calls)
+	}
+}
+
+func TestNewCallbackFloatAndStruct(t *testing.T) {
+	libFileName := filepath.Join(t.TempDir(), "libcbtest.so")
+	if err := buildSharedLib("CC", libFileName, filepath.Join("testdata", "libcbtest", "callback_test.c")); err != nil {
+		t.Fatal(err)
+	}
+	lib, err := purego.Dlopen(libFileName, purego.RTLD_NOW|purego.RTLD_GLOBAL)
+	if err != nil {
+		t.Fatalf("Dlopen(%q) failed: %v", libFileName, err)
+	}
+
+	{
+		var callDoubleCallback func(cb uintptr, a float64, b float32) float64
+		purego.RegisterLibFunc(&callDoubleCallback, lib, "callDoubleCallback")
+		cb := purego.NewCallback(func(a float64, b float32) float64 { return a * float64(b) })
+		defer purego.FreeCallback(cb)
+		if ret := callDoubleCallback(cb, 1.5, 4); ret != 6 {
+			t.Errorf("callDoubleCallback returned %f wanted %f", ret, 6.0)
+		}
+	}
+	{
+		var callFloatCallback func(cb uintptr, a float32) float32
+		purego.RegisterLibFunc(&callFloatCallback, lib, "callFloatCallback")
+		cb := purego.NewCallback(func(a float32) float32 { return -a })
+		defer purego.FreeCallback(cb)
+		if ret := callFloatCallback(cb, 2.5); ret != -2.5 {
+			t.Errorf("callFloatCallback returned %f wanted %f", ret, -2.5)
+		}
+	}
+	{
+		type Vec2 struct{ x, y float32 }
+		var callVec2Callback func(cb uintptr, a, b Vec2) Vec2
+		purego.RegisterLibFunc(&callVec2Callback, lib, "callVec2Callback")
+		cb := purego.NewCallback(func(a, b Vec2) Vec2 { return Vec2{a.x + b.x, a.y + b.y} })
+		defer purego.FreeCallback(cb)
+		if ret := callVec2Callback(cb, Vec2{1, 2}, Vec2{3, 5}); ret != (Vec2{4, 7}) {
+			t.Errorf("callVec2Callback returned %+v wanted %+v", ret, Vec2{4, 7})
+		}
+	}
+	{
+		type Mixed struct {
+			id     int32
+			weight float32
+			count  int64
+		}
+		var callMixedCallback func(cb uintptr, m Mixed) Mixed
+		purego.RegisterLibFunc(&callMixedCallback, lib, "callMixedCallback")
+		// the struct is passed on the stack on amd64 because the integer registers are used up
+		cb := purego.NewCallback(func(a1, a2, a3, a4, a5, a6 int64, m Mixed) Mixed {
+			return Mixed{m.id + 1, m.weight * 2, m.count + a1 + a2 + a3 + a4 + a5 + a6}
+		})
+		defer purego.FreeCallback(cb)
+		want := Mixed{8, 3, 121}
+		if ret := callMixedCallback(cb, Mixed{7, 1.5, 100}); ret != want {
+			t.Errorf("callMixedCallback returned %+v wanted %+v", ret, want)
+		}
+	}
+	{
+		type Big struct{ a, b, c int64 }
+		var callBigCallback func(cb uintptr, n int64, b Big) Big
+		purego.RegisterLibFunc(&callBigCallback, lib, "callBigCallback")
+		cb := purego.NewCallback(func(n int64, b Big) Big { return Big{b.a * n, b.b * n, b.c * n} })
+		defer purego.FreeCallback(cb)
+		want := Big{3, -6, 9}
+		if ret := callBigCallback(cb, 3, Big{1, -2, 3}); ret != want {
+			t.Errorf("callBigCallback returned %+v wanted %+v", ret, want)
+		}
+	}
+	{
+		cb := purego.NewCallback(func(a complex128) complex128 { return a * a })
+		defer purego.FreeCallback(cb)
+		var fn func(a complex128) complex128
+		purego.RegisterFunc(&fn, cb)
+		if ret := fn(1 + 2i); ret != -3+4i {
+			t.Errorf("callback returned %v wanted %v", ret, -3+4i)
+		}
+	}
+}
+
+func TestNewCallbackStringAndFunc(t *testing.T) {
+	libFileName := filepath.Join(t.TempDir(), "libcbtest.so")
+	if err := buildSharedLib("CC", libFileName, filepath.Join("testdata", "libcbtest", "callback_test.c")); err != nil {
+		t.Fatal(err)
+	}
+	lib, err := purego.Dlopen(libFileName, purego.RTLD_NOW|purego.RTLD_GLOBAL)
+	if err != nil {
+		t.Fatalf("Dlopen(%q) failed: %v", libFileName, err)
+	}
+
+	var callStringCallback func(cb uintptr, s string, buf []byte, n uintptr) int32
+	purego.RegisterLibFunc(&callStringCallback, lib, "callStringCallback")
+	cb := purego.NewCallback(func(s string, add func(a, b int32) int32) string {
+		return fmt.Sprintf("%s %d", s, add(40, 2))
+	})
+	defer purego.FreeCallback(cb)
+	buf := make([]byte, 32)
+	const want = "answer 42"
+	if n := callStringCallback(cb, "answer", buf, uintptr(len(buf))); n != int32(len(want)) {
+		t.Errorf("callStringCallback returned %d wanted %d", n, len(want))
+	}
+	if got := string(buf[:len(want)]); got != want {
+		t.Errorf("callback returned %q wanted %q", got, want)
+	}
+}
+
+func TestNewCallbackFuncCache(t *testing.T) {
+	add := purego.NewCallback(func(a, b int32) int32 { return a + b })
+	sub := purego.NewCallback(func(a, b int32) int32 { return a - b })
+	defer purego.FreeCallback(sub)
+	cb := purego.NewCallback(func(op func(a, b int32) int32) int32 {
+		if op == nil {
+			return -1
+		}
+		return op(40, 2)
+	})
+	defer purego.FreeCallback(cb)
+	var fn func(op uintptr) int32
+	purego.RegisterFunc(&fn, cb)
+	// the Go functions are cached by their C function pointer so each one must stay distinct
+	for i := 0; i < 3; i++ {
+		for _, tt := range []struct {
+			op   uintptr
+			want int32
+		}{{add, 42}, {sub, 38}, {0, -1}} {
+			if got := fn(tt.op); got != tt.want {
+				t.Errorf("callback returned %d for %#x wanted %d", got, tt.op, tt.want)
+			}
+		}
+	}
+
+	// a freed callback's address is reused by the next one
+	purego.FreeCallback(add)
+	mul := purego.NewCallback(func(a, b int32) int32 { return a * b })
+	defer purego.FreeCallback(mul)
+	if got := fn(mul); got != 80 {
+		t.Errorf("callback returned %d for %#x wanted %d", got, mul, 80)
+	}
+
+	// more function pointers than are kept are still called
+	for i := int32(0); i < 100; i++ {
+		i := i
+		op := purego.NewCallback(func(a, b int32) int32 { return i })
+		defer purego.FreeCallback(op)
+		if got := fn(op); got != i {
+			t.Errorf("callback returned %d for %#x wanted %d", got, op, i)
+		}
+	}
+}
+
+func TestSetCallbackPanicHandler(t *testing.T) {
+	var gotCB uintptr
+	var gotValue any
+	purego.SetCallbackPanicHandler(func(cb uintptr, v any) any {
+		gotCB, gotValue = cb, v
+		return -1
+	})
+	defer purego.SetCallbackPanicHandler(nil)
+
+	cb := purego.NewCallback(func(a int) int {
+		if a < 0 {
+			panic("negative")
+		}
+		return a * 2
+	})
+	defer purego.FreeCallback(cb)
+	var fn func(a int) int
+	purego.RegisterFunc(&fn, cb)
+	if ret := fn(21); ret != 42 {
+		t.Errorf("callback returned %d wanted %d", ret, 42)
+	}
+	if ret := fn(-1); ret != -1 {
+		t.Errorf("callback returned %d wanted %d", ret, -1)
+	}
+	if gotCB != cb || gotValue != "negative" {
+		t.Errorf("handler got %#x, %v wanted %#x, %v", gotCB, gotValue, cb, "negative")
+	}
+
+	voidCB := purego.NewCallback(func() { panic("void") })
+	defer purego.FreeCallback(voidCB)
+	var voidFn func()
+	purego.RegisterFunc(&voidFn, voidCB)
+	voidFn()
+	if gotValue != "void" {
+		t.Errorf("handler got %v wanted %v", gotValue, "void")
+	}
+
+	// the panic of the argument conversion is recovered too
+	notStringer := purego.NewHandle(1)
+	defer notStringer.Delete()
+	argCB := purego.NewCallback(func(userdata fmt.Stringer) int {
+		t.Errorf("callback called with %v", userdata)
+		return 0
+	})
+	defer purego.FreeCallback(argCB)
+	var argFn func(userdata purego.Handle) int
+	purego.RegisterFunc(&argFn, argCB)
+	if ret := argFn(notStringer); ret != -1 {
+		t.Errorf("callback returned %d wanted %d", ret, -1)
+	}
+	if s, _ := gotValue.(string); !strings.Contains(s, "doesn't implement") {
+		t.Errorf("handler got %v wanted the error of the argument conversion", gotValue)
+	}
+}
+
+func TestSetCallbackPanicHandler_unconvertible(t *testing.T) {
+	var result any
+	purego.SetCallbackPanicHandler(func(cb uintptr, v any) any {
+		return result
+	})
+	defer purego.SetCallbackPanicHandler(nil)
+
+	cb := purego.NewCallback(func(a int) *int {
+		panic("always")
+	})
+	defer purego.FreeCallback(cb)
+	var fn func(a int) *int
+	purego.RegisterFunc(&fn, cb)
+	for _, r := range []any{nil, "not a pointer", 3.5, struct{}{}} {
+		result = r
+		if ret := fn(1); ret != nil {
+			t.Errorf("callback returned %p when the handler returned %#v wanted nil", ret, r)
+		}
+	}
+
+	intCB := purego.NewCallback(func(a int) int32 {
+		panic("always")
+	})
+	defer purego.FreeCallback(intCB)
+	var intFn func(a int) int32
+	purego.RegisterFunc(&intFn, intCB)
+	for r, want := range map[any]int32{nil: 0, "seven": 0, 7.9: 7, uint8(3): 3} {
+		result = r
+		if ret := intFn(1); ret != want {
+			t.Errorf("callback returned %d when the handler returned %#v wanted %d", ret, r, want)
+		}
+	}
+}
+
+func TestNewCallbackAllocs(t *testing.T) {
+	library, err := getSystemLibrary()
+	if err != nil {
+		t.Fatalf("couldn't get system library: %s", err)
+	}
+	libc, err := load.OpenLibrary(library)
+	if err != nil {
+		t.Fatalf("failed to dlopen: %s", err)
+	}
+
+	compare := func(a, b *byte) int32 { return int32(*a) - int32(*b) }
+	x, y := []byte("a\x00"), []byte("b\x00")
+
+	// Calling the callback must not allocate on top of calling a C function
+	// with the same signature and calling compare with reflect.
+	var strcmp func(a, b *byte) int32
+	purego.RegisterLibFunc(&strcmp, libc, "strcmp")
+	want := testing.AllocsPerRun(100, func() { strcmp(&x[0], &y[0]) })
+	args := []reflect.Value{reflect.ValueOf(&x[0]), reflect.ValueOf(&y[0])}
+	want += testing.AllocsPerRun(100, func() { reflect.ValueOf(compare).Call(args) })
+
+	cb := purego.NewCallback(compare)
+	defer purego.FreeCallback(cb)
+	var fn func(a, b *byte) int32
+	purego.RegisterFunc(&fn, cb)
+	if got := testing.AllocsPerRun(100, func() { fn(&x[0], &y[0]) }); got > want {
+		t.Errorf("got %v allocs, want at most %v", got, want)
+	}
+	if ret := fn(&x[0], &y[0]); ret != -1 {
+		t.Errorf("callback returned %d wanted %d", ret, -1)
+	}
+}
+
+func TestNewCallbackConcurrent(t *testing.T) {
+	cb := purego.NewCallback(func(a int) int { return a + 1 })
+	defer purego.FreeCallback(cb)
+	var fn func(a int) int
+	purego.RegisterFunc(&fn, cb)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				if ret := fn(i); ret != i+1 {
+					t.Errorf("callback returned %d wanted %d", ret, i+1)
+					return
+				}
+			}
+		}()
+	}
+	// creating and freeing callbacks must not disturb the calls
+	for i := 0; i < 1000; i++ {
+		purego.FreeCallback(purego.NewCallback(func() {}))
+	}
+	wg.Wait()
+}
+
+func TestFreeCallback(t *testing.T) {
+	cb := purego.NewCallback(func() int { return 1 })
+	purego.FreeCallback(cb)
+	cb2 := purego.NewCallback(func() int { return 2 })
+	defer purego.FreeCallback(cb2)
+	if cb2 != cb {
+		t.Errorf("NewCallback returned %#x wanted the freed callback %#x", cb2, cb)
+	}
+	var fn func() int
+	purego.RegisterFunc(&fn, cb2)
+	if ret := fn(); ret != 2 {
+		t.Errorf("callback returned %d wanted %d", ret, 2)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("FreeCallback of an invalid callback didn't panic")
+		}
+	}()
+	purego.FreeCallback(cb2 + 1)
+}
+
+func TestNewCallbackUnbounded(t *testing.T) {
+	if runtime.GOOS != "linux" || runtime.GOARCH == "loong64" {
+		t.Skip("callbacks are only created at runtime on Linux amd64 and arm64")
+	}
+	// more than the 2000 callbacks in callbackasm
+	const n = 2100
+	cbs := make([]uintptr, n)
+	for i := range cbs {
+		i := i
+		cbs[i] = purego.NewCallback(func(a int) int { return a + i })
+	}
+	defer func() {
+		for _, cb := range cbs {
+			purego.FreeCallback(cb)
+		}
+	}()
+	for _, i := range []int{0, n/2 + 1, n - 1} {
+		var fn func(a int) int
+		purego.RegisterFunc(&fn, cbs[i])
+		if ret := fn(1000); ret != 1000+i {
+			t.Errorf("callback %d returned %d wanted %d", i, ret, 1000+i)
+		}
+	}
+}
+
+func TestNewCallbackHandleValue(t *testing.T) {
+	type state struct{ calls int }
+	s := &state{}
+	h := purego.NewHandle(s)
+	defer h.Delete()
+
+	cb := purego.NewCallback(func(n int, userdata any) int {
+		st := userdata.(*state)
+		st.calls += n
+		return st.calls
+	})
+	var fn func(n int, userdata purego.Handle) int
+	purego.RegisterFunc(&fn, cb)
+	fn(1, h)
+	if ret := fn(2, h); ret != 3 || s.calls != 3 {
+		t.Errorf("callback returned %d with %d calls wanted 3", ret, s.calls)
+	}
+
+	stringer := purego.NewHandle(time.Second)
+	defer stringer.Delete()
+	cbStringer := purego.NewCallback(func(userdata fmt.Stringer) string {
+		return userdata.String()
+	})
+	var fnStringer func(userdata purego.Handle) string
+	purego.RegisterFunc(&fnStringer, cbStringer)
+	if ret := fnStringer(stringer); ret != "1s" {
+		t.Errorf("callback returned %q wanted %q", ret, "1s")
+	}
+
+	cbNil := purego.NewCallback(func(userdata any) bool {
+		return userdata == nil
+	})
+	var fnNil func(userdata purego.Handle) bool
+	purego.RegisterFunc(&fnNil, cbNil)
+	if !fnNil(0) {
+		t.Errorf("callback didn't receive nil for a NULL userdata")
+	}
+}
+
+func TestRegisterFunc_returns(t *testing.T) {
+	{
+		inner := purego.NewCallback(func() int32 { return 42 })
+		outer := purego.NewCallback(func() uintptr { return inner })
+		var fn func() func() int32
+		purego.RegisterFunc(&fn, outer)
+		if got := fn()(); got != 42 {
+			t.Errorf("func return not correct got %d but wanted %d", got, 42)
+		}
+	}
+	{
+		type IntPtr *int
+		x := 5
+		cb := purego.NewCallback(func() *int { return &x })
+		var fn func() IntPtr
+		purego.RegisterFunc(&fn, cb)
+		if got := fn(); got != &x {
+			t.Errorf("named pointer return not correct got %p but wanted %p", got, &x)
+		}
+	}
+}
+
 func ExampleNewCallback() {
 	cb := purego.NewCallback(func(a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14, a15 int) int {
 		fmt.Println(a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14, a15)
@@ -0,0 +1,272 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build darwin || freebsd || (linux && (amd64 || arm64 || loong64)) || netbsd
+
+package purego
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/ebitengine/purego/internal/strings"
+)
+
+// callback is a Go function created with NewCallback together with the plan
+// for reading its arguments which is computed once when it is created.
+type callback struct {
+	fn     reflect.Value
+	addr   uintptr // the address C calls
+	args   []callbackArg
+	result callbackResult
+	values sync.Pool // *[]reflect.Value with an element for each argument
+
+	funcs    sync.Map // map[callbackFuncKey]reflect.Value, see funcValue
+	numFuncs int32    // the number of entries in funcs
+}
+
+// callbackArgKind describes how a single argument is read from the frame.
+type callbackArgKind uint8
+
+const (
+	callbackArgZero      callbackArgKind = iota // the CDecl marker
+	callbackArgWord                             // the value at pos
+	callbackArgString                           // a char * at pos
+	callbackArgFunc                             // a C function pointer at pos
+	callbackArgComplex64                        // the float32 parts at pos and pos+1
+	callbackArgStruct                           // a struct read by callbackStructArg
+	callbackArgHandle                           // the Value of a Handle at pos
+)
+
+type callbackArg struct {
+	kind  callbackArgKind
+	ty    reflect.Type
+	pos   int           // the index of the word in the frame
+	frame callbackFrame // the position in the frame before a struct
+}
+
+// callbackResult describes where a struct result that doesn't fit in registers goes.
+type callbackResult uint8
+
+const (
+	callbackResultRegisters callbackResult = iota
+	callbackResultHidden                   // the caller passes the address as a hidden first argument
+	callbackResultR8                       // arm64 passes the address in R8 which is saved in callbackArgs.result
+)
+
+// callbackFrame reads words from the argument frame of callbackasm1.
+// It only counts them if args is nil.
+type callbackFrame struct {
+	args   unsafe.Pointer
+	ints   int // the number of integer registers read
+	floats int // the number of float registers read
+	stack  int // the index of the next stack word. The stack begins after the float and integer registers.
+}
+
+func (f *callbackFrame) word(pos int) uintptr {
+	if f.args == nil {
+		return 0
+	}
+	return *(*uintptr)(unsafe.Add(f.args, uintptr(pos)*ptrSize))
+}
+
+func (f *callbackFrame) nextStack() uintptr {
+	x := f.word(f.stack)
+	f.stack++
+	return x
+}
+
+func (f *callbackFrame) nextInt() uintptr {
+	if f.ints >= numOfIntegerRegisters() {
+		return f.nextStack()
+	}
+	// the integers begin after the floats in frame
+	x := f.word(f.ints + numOfFloatRegisters)
+	f.ints++
+	return x
+}
+
+func (f *callbackFrame) nextFloat() uintptr {
+	if f.floats >= numOfFloatRegisters {
+		return f.nextStack()
+	}
+	x := f.word(f.floats)
+	f.floats++
+	return x
+}
+
+// newCallback computes where each argument of fn is in the frame.
+func newCallback(fn reflect.Value) *callback {
+	ty := fn.Type()
+	c := &callback{fn: fn, args: make([]callbackArg, ty.NumIn())}
+	c.values.New = func() any {
+		values := make([]reflect.Value, ty.NumIn())
+		return &values
+	}
+	f := callbackFrame{stack: numOfIntegerRegisters() + numOfFloatRegisters}
+	if ty.NumOut() == 1 && ty.Out(0).Kind() == reflect.Struct && ty.Out(0).Size() > maxRegAllocStructSize {
+		switch runtime.GOARCH {
+		case "amd64", "loong64":
+			c.result = callbackResultHidden
+			f.nextInt()
+		case "arm64":
+			if isAllFloats, numFields := isAllSameFloat(ty.Out(0)); !isAllFloats || numFields > 4 {
+				c.result = callbackResultR8
+			}
+		}
+	}
+	for i := range c.args {
+		in := ty.In(i)
+		arg := callbackArg{kind: callbackArgWord, ty: in}
+		switch in.Kind() {
+		case reflect.Float32, reflect.Float64:
+			if f.floats >= numOfFloatRegisters {
+				arg.pos = f.stack
+				f.stack++
+			} else {
+				arg.pos = f.floats
+			}
+			f.floats++
+		case reflect.Complex64, reflect.Complex128:
+			// complex numbers are passed like a struct of the real and imaginary parts
+			words := int(in.Size() / ptrSize)
+			regs := 2 // one float register for each part
+			if runtime.GOARCH == "amd64" {
+				regs = words // the parts are packed into eightbytes
+			}
+			switch {
+			case f.floats+regs <= numOfFloatRegisters:
+				if regs == 2 && words == 1 {
+					// float _Complex uses two float registers which aren't next to each other in memory
+					arg.kind = callbackArgComplex64
+				}
+				arg.pos = f.floats
+				f.floats += regs
+			case runtime.GOARCH == "loong64" && f.ints+words <= numOfIntegerRegisters():
+				// LoongArch passes it in the integer registers when there aren't enough float registers
+				arg.pos = f.ints + numOfFloatRegisters
+				f.ints += words
+			default:
+				arg.pos = f.stack
+				f.stack += words
+				if runtime.GOARCH == "arm64" {
+					f.floats = numOfFloatRegisters
+				}
+			}
+		case reflect.Struct:
+			if i == 0 && in.AssignableTo(reflect.TypeOf(CDecl{})) {
+				arg.kind = callbackArgZero
+				break
+			}
+			arg.kind = callbackArgStruct
+			arg.frame = f
+			// count the words of the struct
+			callbackStructArg(in, &f.ints, &f.floats, f.nextInt, f.nextFloat, f.nextStack)
+		default:
+			switch in.Kind() {
+			case reflect.String:
+				arg.kind = callbackArgString
+			case reflect.Func:
+				arg.kind = callbackArgFunc
+			case reflect.Interface:
+				arg.kind = callbackArgHandle
+			}
+			if f.ints >= numOfIntegerRegisters() {
+				arg.pos = f.stack
+				f.stack++
+			} else {
+				arg.pos = f.ints + numOfFloatRegisters
+			}
+			f.ints++
+		}
+		c.args[i] = arg
+	}
+	return c
+}
+
+// readArgs reads the arguments from frame into values.
+func (c *callback) readArgs(frame unsafe.Pointer, values []reflect.Value) {
+	for i, arg := range c.args {
+		// the frame has no fixed size since stack arguments are read from the caller's frame
+		p := unsafe.Add(frame, uintptr(arg.pos)*ptrSize)
+		switch arg.kind {
+		case callbackArgZero:
+			values[i] = reflect.Zero(arg.ty)
+		case callbackArgWord:
+			values[i] = reflect.NewAt(arg.ty, p).Elem()
+		case callbackArgString:
+			// copy the char * so that the string stays valid after the callback returns
+			v := reflect.New(arg.ty).Elem()
+			v.SetString(strings.GoString(*(*uintptr)(p)))
+			values[i] = v
+		case callbackArgFunc:
+			values[i] = c.funcValue(arg.ty, *(*uintptr)(p))
+		case callbackArgHandle:
+			values[i] = handleValue(arg.ty, Handle(*(*uintptr)(p)))
+		case callbackArgComplex64:
+			re := *(*float32)(p)
+			im := *(*float32)(unsafe.Add(p, ptrSize))
+			values[i] = reflect.ValueOf(complex(re, im)).Convert(arg.ty)
+		case callbackArgStruct:
+			f := arg.frame
+			f.args = frame
+			values[i] = callbackStructArg(arg.ty, &f.ints, &f.floats, f.nextInt, f.nextFloat, f.nextStack)
+		}
+	}
+}
+
+// handleValue returns the Value of h as the interface type ty. NULL is the nil interface.
+func handleValue(ty reflect.Type, h Handle) reflect.Value {
+	v := reflect.New(ty).Elem()
+	if h == 0 {
+		return v
+	}
+	x := h.Value()
+	if x == nil {
+		return v
+	}
+	xv := reflect.ValueOf(x)
+	if !xv.Type().Implements(ty) {
+		panic("purego: the Value of the Handle passed to a callback is a " + xv.Type().String() + " which doesn't implement " + ty.String())
+	}
+	v.Set(xv)
+	return v
+}
+
+// callbackFuncKey identifies a Go function wrapping a C function pointer.
+type callbackFuncKey struct {
+	ty  reflect.Type
+	cfn uintptr
+}
+
+// maxCallbackFuncs is the number of Go functions wrapping C function pointers that a
+// callback keeps.
+const maxCallbackFuncs = 64
+
+// funcValue returns the Go function of type ty wrapping the C function pointer cfn passed to
+// the callback. NULL is a nil function. C APIs usually pass the same few function pointers
+// over and over so the first maxCallbackFuncs are wrapped by RegisterFunc only once and kept
+// until FreeCallback releases the callback.
+func (c *callback) funcValue(ty reflect.Type, cfn uintptr) reflect.Value {
+	if cfn == 0 {
+		return reflect.Zero(ty)
+	}
+	key := callbackFuncKey{ty, cfn}
+	if v, ok := c.funcs.Load(key); ok {
+		return v.(reflect.Value)
+	}
+	v := reflect.New(ty)
+	RegisterFunc(v.Interface(), cfn)
+	if atomic.AddInt32(&c.numFuncs, 1) > maxCallbackFuncs {
+		atomic.AddInt32(&c.numFuncs, -1)
+		return v.Elem()
+	}
+	actual, loaded := c.funcs.LoadOrStore(key, v.Elem())
+	if loaded {
+		atomic.AddInt32(&c.numFuncs, -1)
+	}
+	return actual.(reflect.Value)
+}
@@ -0,0 +1,206 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build darwin || freebsd || linux || netbsd || windows
+
+package purego
+
+import (
+	"math"
+	"reflect"
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"github.com/ebitengine/purego/internal/strings"
+)
+
+// argConv describes how a Go argument is converted into a single machine word.
+type argConv uint8
+
+const (
+	convSkip argConv = iota // the Variadic marker
+	convInt
+	convUint
+	convBool
+	convPointer
+	convString
+	convFloat32
+	convFloat64
+)
+
+// argSlot describes where a single argument is placed for the call.
+type argSlot struct {
+	conv  argConv
+	float bool  // index refers to the float registers instead of the integer registers and stack
+	index uint8 // index into syscall15Args.ints() or syscall15Args.floats()
+}
+
+// callPlan is the placement of every argument of a function type computed once
+// when it is registered. Calling through a plan doesn't need to classify arguments
+// again and doesn't allocate for integer, pointer, float and null-terminated
+// string arguments.
+//
+// Plans only exist for signatures made entirely of those kinds. Everything else
+// (structs, callbacks, variadic any) uses the generic path in RegisterFunc.
+type callPlan struct {
+	ty      reflect.Type
+	slots   []argSlot
+	outType reflect.Type // nil if the function has no result
+	errno   bool         // the last result receives errno
+	nstack  uintptr      // the number of arguments placed on the stack
+}
+
+// callPlans caches the plan of each function type. A nil plan is stored
+// for function types which must use the generic path.
+var callPlans sync.Map // map[reflect.Type]*callPlan
+
+// getCallPlan returns the plan for ty or nil if ty can't use one.
+func getCallPlan(ty reflect.Type) *callPlan {
+	if p, ok := callPlans.Load(ty); ok {
+		return p.(*callPlan)
+	}
+	p, _ := callPlans.LoadOrStore(ty, compileCallPlan(ty))
+	return p.(*callPlan)
+}
+
+func compileCallPlan(ty reflect.Type) *callPlan {
+	switch runtime.GOARCH {
+	case "amd64":
+		if runtime.GOOS == "windows" {
+			// Windows amd64 places arguments in numbered registers using syscall.SyscallN.
+			return nil
+		}
+	case "arm64", "loong64":
+	default:
+		return nil
+	}
+	p := &callPlan{
+		ty:      ty,
+		slots:   make([]argSlot, ty.NumIn()),
+		outType: returnType(ty),
+		errno:   hasErrnoResult(ty),
+	}
+	var ints, floats, stack int
+	var isVariadic bool
+	for i := 0; i < ty.NumIn(); i++ {
+		var slot argSlot
+		if ty.In(i) == variadicType {
+			if runtime.GOOS == "darwin" && runtime.GOARCH == "arm64" {
+				// Apple arm64 places variadic arguments on the stack
+				return nil
+			}
+			isVariadic = true
+			p.slots[i] = slot
+			continue
+		}
+		switch in := ty.In(i); in.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			slot.conv = convInt
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			slot.conv = convUint
+		case reflect.Bool:
+			slot.conv = convBool
+		case reflect.Slice:
+			if in.Elem().Kind() == reflect.Interface {
+				// []any is expanded into the arguments it contains
+				return nil
+			}
+			slot.conv = convPointer
+		case reflect.Ptr, reflect.UnsafePointer:
+			slot.conv = convPointer
+		case reflect.String:
+			slot.conv = convString
+		case reflect.Float32:
+			slot.conv = convFloat32
+			if isVariadic {
+				// promoted to double
+				slot.conv = convFloat64
+			}
+		case reflect.Float64:
+			slot.conv = convFloat64
+		default:
+			return nil
+		}
+		isFloat := slot.conv == convFloat32 || slot.conv == convFloat64
+		if isVariadic && runtime.GOARCH == "loong64" {
+			// LoongArch passes variadic floats in the integer registers
+			isFloat = false
+		}
+		switch {
+		case isFloat && floats < numOfFloatRegisters:
+			slot.float = true
+			slot.index = uint8(floats)
+			floats++
+		case !isFloat && ints < numOfIntegerRegisters():
+			slot.index = uint8(ints)
+			ints++
+		default:
+			slot.index = uint8(numOfIntegerRegisters() + stack)
+			stack++
+		}
+		p.slots[i] = slot
+	}
+	if stack > 0 && runtime.GOOS == "darwin" && runtime.GOARCH == "arm64" {
+		// Darwin arm64 packs stack arguments by their natural alignment.
+		return nil
+	}
+	if numOfIntegerRegisters()+stack > maxArgs {
+		return nil
+	}
+	if p.outType != nil && p.outType.Kind() == reflect.Struct {
+		return nil
+	}
+	p.nstack = uintptr(stack)
+	return p
+}
+
+// call fills syscall15Args according to p and calls cfn.
+func (p *callPlan) call(cfn uintptr, args []reflect.Value) []reflect.Value {
+	// copied strings only need to stay alive until the call returns
+	var keepAlive [maxArgs]*byte
+
+	syscall := thePool.Get().(*syscall15Args)
+	defer thePool.Put(syscall)
+
+	*syscall = syscall15Args{fn: cfn, nstack: p.nstack}
+	if p.errno {
+		syscall.errnoFn = errnoLocation
+	}
+	ints, floats := syscall.ints(), syscall.floats()
+	for i, slot := range p.slots {
+		var x uintptr
+		switch v := args[i]; slot.conv {
+		case convSkip:
+			continue
+		case convInt:
+			x = uintptr(v.Int())
+		case convUint:
+			x = uintptr(v.Uint())
+		case convBool:
+			if v.Bool() {
+				x = 1
+			}
+		case convPointer:
+			x = v.Pointer()
+		case convString:
+			ptr := strings.CString(v.String())
+			keepAlive[slot.index] = ptr
+			x = uintptr(unsafe.Pointer(ptr))
+		case convFloat32:
+			x = uintptr(math.Float32bits(float32(v.Float())))
+		case convFloat64:
+			x = uintptr(math.Float64bits(v.Float()))
+		}
+		if slot.float {
+			floats[slot.index] = x
+		} else {
+			ints[slot.index] = x
+		}
+	}
+	runtime_cgocall(syscall15XABI0, unsafe.Pointer(syscall))
+	runtime.KeepAlive(keepAlive)
+	runtime.KeepAlive(args)
+
+	return makeResults(p.ty, p.outType, syscall, args)
+}
@@ -0,0 +1,635 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	gotoken "go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type typeContext uint8
+
+const (
+	ctxField typeContext = iota
+	ctxParam
+	ctxResult
+	ctxElem
+)
+
+// target describes the data model of the platform the bindings are generated for.
+type target struct {
+	ptrSize uintptr
+	align64 uintptr // the alignment of 8 byte integers and doubles in structs
+}
+
+type generator struct {
+	p        *parser
+	pp       *preprocessor
+	target   target
+	platform string // the GOOS/GOARCH of the target
+	pkg      string
+	lib      string // the name of the struct holding the functions
+	sources  []string
+
+	names      map[string]bool // the Go names at the top level
+	recordName map[*record]string
+	typeName   map[string]string // the Go name of each typedef
+	consts     map[string]bool
+	usesUnsafe bool
+	usesPurego bool // whether any function was bound
+
+	buf bytes.Buffer
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// generate returns the formatted Go source of the bindings.
+func (g *generator) generate() ([]byte, error) {
+	g.names = map[string]bool{g.lib: true}
+	g.recordName = map[*record]string{}
+	g.typeName = map[string]string{}
+	g.consts = map[string]bool{}
+	g.nameTypes()
+
+	var body bytes.Buffer
+	g.buf, body = body, g.buf
+	g.enums()
+	g.defines()
+	g.records()
+	g.typedefs()
+	g.functions()
+	g.buf, body = body, g.buf
+
+	g.printf("// Code generated by purego-bindgen from %s for %s. DO NOT EDIT.\n\n", strings.Join(g.sources, ", "), g.platform)
+	g.printf("package %s\n\n", g.pkg)
+	switch {
+	case g.usesUnsafe && g.usesPurego:
+		g.printf("import (\n\t\"unsafe\"\n\n\t\"github.com/ebitengine/purego\"\n)\n\n")
+	case g.usesUnsafe:
+		g.printf("import \"unsafe\"\n\n")
+	case g.usesPurego:
+		g.printf("import \"github.com/ebitengine/purego\"\n\n")
+	}
+	g.buf.Write(body.Bytes())
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return g.buf.Bytes(), fmt.Errorf("formatting the generated code: %v", err)
+	}
+	return src, nil
+}
+
+// exportName returns the exported Go name of the C identifier name.
+func exportName(name string) string {
+	name = strings.TrimLeft(name, "_")
+	if name == "" {
+		return "X"
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// unique returns name or name with underscores appended that isn't in used yet and adds it.
+func unique(used map[string]bool, name string) string {
+	for used[name] {
+		name += "_"
+	}
+	used[name] = true
+	return name
+}
+
+// nameTypes assigns the Go names of the records and typedefs.
+func (g *generator) nameTypes() {
+	for _, rec := range g.p.recordList {
+		switch {
+		case rec.typedef != "":
+			g.recordName[rec] = unique(g.names, exportName(rec.typedef))
+		case rec.tag != "":
+			g.recordName[rec] = unique(g.names, exportName(rec.tag))
+		}
+	}
+	for _, td := range g.p.typedefList {
+		if (td.ty.kind == kindRecord || td.ty.kind == kindEnum) && td.ty.rec.typedef == td.name {
+			g.typeName[td.name] = g.recordName[td.ty.rec]
+			continue
+		}
+		g.typeName[td.name] = unique(g.names, exportName(td.name))
+	}
+}
+
+func (g *generator) enums() {
+	for _, rec := range g.p.recordList {
+		if rec.keyword != "enum" || !rec.complete {
+			continue
+		}
+		name := g.recordName[rec]
+		if rec.err != "" {
+			g.printf("// %s is skipped: %s\n\n", name, rec.err)
+			continue
+		}
+		typ := ""
+		if name != "" {
+			g.printf("type %s int32\n\n", name)
+			typ = " " + name
+		}
+		g.printf("const (\n")
+		for _, e := range rec.enumerators {
+			g.names[e.name] = true
+			g.consts[e.name] = true
+			g.printf("%s%s = %d\n", e.name, typ, g.p.enumConst[e.name])
+		}
+		g.printf(")\n\n")
+	}
+}
+
+// defines translates the object-like macros that are constant expressions.
+func (g *generator) defines() {
+	var lines, skipped []string
+	for _, m := range g.pp.defines {
+		if !m.user || g.pp.macros[m.name] != m || g.names[m.name] || gotoken.IsKeyword(m.name) || len(m.body) == 0 {
+			continue
+		}
+		expr, err := g.constExpr(m.body)
+		if err != nil {
+			// the body may use function-like macros
+			if expr, err = g.constExpr(g.pp.expand(m.body, map[string]bool{m.name: true})); err != nil {
+				skipped = append(skipped, fmt.Sprintf("// %s is skipped: %v\n", m.name, err))
+				continue
+			}
+		}
+		g.names[m.name] = true
+		g.consts[m.name] = true
+		lines = append(lines, fmt.Sprintf("%s = %s\n", m.name, expr))
+	}
+	if len(lines) == 0 {
+		for _, s := range skipped {
+			g.printf("%s", s)
+		}
+		if len(skipped) > 0 {
+			g.printf("\n")
+		}
+		return
+	}
+	if len(skipped) > 0 {
+		lines = append(lines, "\n")
+		for _, s := range skipped {
+			lines = append(lines, "\t"+s)
+		}
+	}
+	g.printf("const (\n%s)\n\n", strings.Join(lines, ""))
+}
+
+// constExpr translates the body of a macro into a Go constant expression.
+func (g *generator) constExpr(toks []token) (string, error) {
+	var b strings.Builder
+	var unsigned, complement bool
+	for i, t := range toks {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		switch t.kind {
+		case tokNumber:
+			lit := t.text
+			isHex := strings.HasPrefix(lit, "0x") || strings.HasPrefix(lit, "0X")
+			if !isHex && strings.ContainsAny(lit, ".eE") {
+				lit = strings.TrimRight(lit, "fFlL")
+				if _, err := strconv.ParseFloat(lit, 64); err != nil {
+					return "", fmt.Errorf("invalid number %s", t.text)
+				}
+			} else {
+				unsigned = unsigned || strings.ContainsAny(lit, "uU")
+				lit = strings.TrimRight(lit, "uUlL")
+				if _, err := parseInt(lit); err != nil {
+					return "", fmt.Errorf("invalid number %s", t.text)
+				}
+			}
+			b.WriteString(lit)
+		case tokString, tokChar:
+			if _, err := strconv.Unquote(t.text); err != nil {
+				return "", fmt.Errorf("invalid literal %s", t.text)
+			}
+			if i > 0 && toks[i-1].kind == tokString && t.kind == tokString {
+				// adjacent strings are concatenated
+				b.WriteString("+ ")
+			}
+			b.WriteString(t.text)
+		case tokIdent:
+			if !g.consts[t.text] {
+				return "", fmt.Errorf("%s is not a constant", t.text)
+			}
+			b.WriteString(t.text)
+		case tokPunct:
+			switch t.text {
+			case "(", ")", "+", "-", "*", "/", "%", "<<", ">>", "&", "|", "^":
+				b.WriteString(t.text)
+			case "~":
+				complement = true
+				b.WriteString("^")
+			default:
+				return "", fmt.Errorf("operator %s is not supported", t.text)
+			}
+		}
+	}
+	if unsigned && complement {
+		return "", errors.New("the complement of an unsigned constant depends on the width of its C type")
+	}
+	return b.String(), nil
+}
+
+func (g *generator) records() {
+	for _, rec := range g.p.recordList {
+		name := g.recordName[rec]
+		if rec.keyword == "enum" || name == "" {
+			continue
+		}
+		if !rec.complete {
+			g.printf("// %s is opaque and only used through pointers.\n", name)
+			g.printf("type %s struct{}\n\n", name)
+			continue
+		}
+		def, _, _, err := g.recordType(rec)
+		if err != nil {
+			g.printf("// %s is skipped: %v\n\n", name, err)
+			continue
+		}
+		if rec.keyword == "union" {
+			g.printf("// %s is a union. Its members are accessed through unsafe.Pointer.\n", name)
+		}
+		g.printf("type %s %s\n\n", name, def)
+	}
+}
+
+func (g *generator) typedefs() {
+	for _, td := range g.p.typedefList {
+		name := g.typeName[td.name]
+		ty := td.ty
+		if (ty.kind == kindRecord || ty.kind == kindEnum) && ty.rec.typedef == td.name {
+			// the record itself is named after the typedef
+			continue
+		}
+		switch {
+		case ty.kind == kindRecord && g.recordName[ty.rec] != "":
+			g.printf("type %s = %s\n\n", name, g.recordName[ty.rec])
+			continue
+		case ty.kind == kindPointer && resolve(ty.elem).kind == kindFunc:
+			g.printf("// %s is a C function pointer like the result of purego.NewCallback.\n", name)
+		}
+		goType, err := g.goType(ty, ctxField)
+		if err != nil {
+			g.printf("// %s is skipped: %v\n\n", name, err)
+			continue
+		}
+		g.printf("type %s %s\n\n", name, goType)
+	}
+}
+
+func (g *generator) functions() {
+	type binding struct {
+		field, sym string
+	}
+	var bindings []binding
+	var skipped []string
+	fields := map[string]bool{}
+	var decls bytes.Buffer
+	for _, fn := range g.p.funcs {
+		sig, err := g.signature(fn.ty)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("// %s is skipped: %v\n", fn.name, err))
+			continue
+		}
+		field := unique(fields, exportName(fn.name))
+		fmt.Fprintf(&decls, "%s %s `purego:\"sym=%s\"`\n", field, sig, fn.name)
+		bindings = append(bindings, binding{field, fn.name})
+	}
+	sort.Strings(skipped)
+	if len(bindings) == 0 {
+		// without functions there is nothing to register and purego isn't used
+		for _, s := range skipped {
+			g.printf("%s", s)
+		}
+		return
+	}
+	g.usesPurego = true
+	g.printf("// %s holds the functions of the library.\n", g.lib)
+	g.printf("type %s struct {\n", g.lib)
+	g.buf.Write(decls.Bytes())
+	if len(skipped) > 0 {
+		g.printf("\n")
+		for _, s := range skipped {
+			g.printf("%s", s)
+		}
+	}
+	g.printf("}\n\n")
+	g.printf("// Register registers every function of l with purego.RegisterLibFunc.\n")
+	g.printf("// It panics if a symbol is missing. Use purego.RegisterLibFuncs(l, handle) to get an error instead.\n")
+	g.printf("func (l *%s) Register(handle uintptr) {\n", g.lib)
+	for _, b := range bindings {
+		g.printf("purego.RegisterLibFunc(&l.%s, handle, %q)\n", b.field, b.sym)
+	}
+	g.printf("}\n")
+}
+
+// signature returns the Go function type of the C function type fn.
+func (g *generator) signature(fn *cType) (string, error) {
+	named := fn.variadic
+	for _, p := range fn.params {
+		if p.name != "" {
+			named = true
+		}
+	}
+	used := map[string]bool{}
+	var params []string
+	for i, p := range fn.params {
+		ty, err := g.goType(p.ty, ctxParam)
+		if err != nil {
+			return "", fmt.Errorf("parameter %s: %v", paramName(p.name, i), err)
+		}
+		if hasFloatUnion(p.ty) {
+			return "", fmt.Errorf("parameter %s: %v", paramName(p.name, i), errFloatUnion)
+		}
+		if named {
+			name := p.name
+			if name == "" {
+				name = fmt.Sprintf("arg%d", i)
+			}
+			if gotoken.IsKeyword(name) || name == "purego" || name == "unsafe" {
+				name += "_"
+			}
+			ty = unique(used, name) + " " + ty
+		}
+		params = append(params, ty)
+	}
+	if fn.variadic {
+		params = append(params, "_ purego.Variadic", unique(used, "args")+" ...any")
+	}
+	sig := "func(" + strings.Join(params, ", ") + ")"
+	if resolve(fn.elem).kind == kindVoid {
+		return sig, nil
+	}
+	result, err := g.goType(fn.elem, ctxResult)
+	if err != nil {
+		return "", fmt.Errorf("result: %v", err)
+	}
+	if hasFloatUnion(fn.elem) {
+		return "", fmt.Errorf("result: %v", errFloatUnion)
+	}
+	return sig + " " + result, nil
+}
+
+// paramName returns the name of the i-th parameter for errors.
+func paramName(name string, i int) string {
+	if name != "" {
+		return name
+	}
+	return strconv.Itoa(i + 1)
+}
+
+// errFloatUnion is why a union with float members isn't passed by value. A union becomes an
+// array of integers in Go which is passed in integer registers but C may use float registers.
+var errFloatUnion = errors.New("a union with float members can't be passed by value")
+
+// hasFloatUnion reports whether the value of ty contains a union with float members.
+func hasFloatUnion(ty *cType) bool {
+	ty = resolve(ty)
+	switch ty.kind {
+	case kindArray:
+		return hasFloatUnion(ty.elem)
+	case kindRecord:
+		for _, f := range ty.rec.fields {
+			if ty.rec.keyword == "union" && hasFloat(f.ty) || hasFloatUnion(f.ty) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasFloat reports whether the value of ty contains a float.
+func hasFloat(ty *cType) bool {
+	ty = resolve(ty)
+	switch ty.kind {
+	case kindPrim:
+		switch ty.name {
+		case "float32", "float64", "complex64", "complex128":
+			return true
+		}
+	case kindArray:
+		return hasFloat(ty.elem)
+	case kindRecord:
+		for _, f := range ty.rec.fields {
+			if hasFloat(f.ty) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolve returns the type a typedef refers to.
+func resolve(ty *cType) *cType {
+	for ty.kind == kindNamed {
+		ty = ty.elem
+	}
+	return ty
+}
+
+// goType returns the Go type of ty.
+func (g *generator) goType(ty *cType, ctx typeContext) (string, error) {
+	if ty.err != "" {
+		return "", errors.New(ty.err)
+	}
+	switch ty.kind {
+	case kindVoid:
+		return "", errors.New("void can't be a value")
+	case kindPrim:
+		return ty.name, nil
+	case kindNamed:
+		inner := ctxField
+		if ctx == ctxElem {
+			inner = ctxElem
+		}
+		if _, err := g.goType(ty.elem, inner); err != nil {
+			return "", fmt.Errorf("%s: %v", ty.name, err)
+		}
+		return g.typeName[ty.name], nil
+	case kindEnum:
+		if name := g.recordName[ty.rec]; name != "" && ty.rec.complete && ty.rec.err == "" {
+			return name, nil
+		}
+		return "int32", nil
+	case kindRecord:
+		if name := g.recordName[ty.rec]; name != "" {
+			if !ty.rec.complete && ctx == ctxElem {
+				// an opaque struct behind a pointer
+				return name, nil
+			}
+			if _, _, _, err := g.recordType(ty.rec); err != nil {
+				return "", fmt.Errorf("%s: %v", name, err)
+			}
+			return name, nil
+		}
+		if ctx != ctxField && ctx != ctxElem {
+			return "", fmt.Errorf("anonymous %s", ty.rec.keyword)
+		}
+		def, _, _, err := g.recordType(ty.rec)
+		return def, err
+	case kindPointer:
+		elem := resolve(ty.elem)
+		switch {
+		case elem.kind == kindVoid:
+			g.usesUnsafe = true
+			return "unsafe.Pointer", nil
+		case elem.kind == kindFunc:
+			return "uintptr", nil
+		case ty.elem.kind == kindPrim && (ty.elem.name == "byte" || ty.elem.name == "int8") && ty.elem.isConst && (ctx == ctxParam || ctx == ctxResult):
+			// purego copies strings to and from C
+			return "string", nil
+		case elem.kind == kindRecord && !elem.rec.complete && g.recordName[elem.rec] == "":
+			g.usesUnsafe = true
+			return "unsafe.Pointer", nil
+		}
+		s, err := g.goType(ty.elem, ctxElem)
+		if err != nil {
+			// pointers to unknown types are still pointers
+			g.usesUnsafe = true
+			return "unsafe.Pointer", nil
+		}
+		return "*" + s, nil
+	case kindArray:
+		if ctx == ctxParam {
+			return g.goType(&cType{kind: kindPointer, elem: ty.elem}, ctxParam)
+		}
+		if ty.n < 0 {
+			return "", errors.New("flexible array members are not supported")
+		}
+		s, err := g.goType(ty.elem, ctxElem)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("[%d]%s", ty.n, s), nil
+	case kindFunc:
+		if ctx == ctxParam {
+			return "uintptr", nil
+		}
+		return "", errors.New("function types are only supported as parameters")
+	}
+	return "", fmt.Errorf("unsupported type %s", ty)
+}
+
+// sizeAlign returns the size and alignment of ty in C.
+func (g *generator) sizeAlign(ty *cType) (size, align uintptr, err error) {
+	if ty.err != "" {
+		return 0, 0, errors.New(ty.err)
+	}
+	switch ty.kind {
+	case kindPrim:
+		switch ty.name {
+		case "bool", "byte", "int8", "uint8":
+			return 1, 1, nil
+		case "int16", "uint16":
+			return 2, 2, nil
+		case "int32", "uint32", "float32":
+			return 4, 4, nil
+		case "complex64":
+			return 8, 4, nil
+		case "int64", "uint64", "float64":
+			return 8, g.target.align64, nil
+		case "complex128":
+			return 16, g.target.align64, nil
+		case "int", "uint", "uintptr":
+			return g.target.ptrSize, g.target.ptrSize, nil
+		}
+	case kindNamed:
+		return g.sizeAlign(ty.elem)
+	case kindEnum:
+		return 4, 4, nil
+	case kindPointer:
+		return g.target.ptrSize, g.target.ptrSize, nil
+	case kindArray:
+		if ty.n < 0 {
+			return 0, 0, errors.New("flexible array members are not supported")
+		}
+		size, align, err := g.sizeAlign(ty.elem)
+		return size * uintptr(ty.n), align, err
+	case kindRecord:
+		_, size, align, err := g.recordType(ty.rec)
+		return size, align, err
+	}
+	return 0, 0, fmt.Errorf("%s has no size", ty)
+}
+
+// recordType returns the Go struct type of a C struct or union with explicit padding fields
+// wherever C inserts padding, which keeps the layout independent of Go's alignment rules.
+func (g *generator) recordType(rec *record) (def string, size, align uintptr, err error) {
+	if rec.err != "" {
+		return "", 0, 0, errors.New(rec.err)
+	}
+	if !rec.complete {
+		return "", 0, 0, fmt.Errorf("%s %s is incomplete", rec.keyword, rec.tag)
+	}
+	align = 1
+	if rec.keyword == "union" {
+		for _, f := range rec.fields {
+			fsize, falign, err := g.sizeAlign(f.ty)
+			if err != nil {
+				return "", 0, 0, fmt.Errorf("member %s: %v", f.name, err)
+			}
+			if fsize > size {
+				size = fsize
+			}
+			if falign > align {
+				align = falign
+			}
+		}
+		size = (size + align - 1) &^ (align - 1)
+		word := map[uintptr]string{1: "uint8", 2: "uint16", 4: "uint32", 8: "uint64"}[align]
+		if word == "" {
+			return "", 0, 0, fmt.Errorf("unsupported alignment %d", align)
+		}
+		return fmt.Sprintf("struct {\n_ [%d]%s\n}", size/align, word), size, align, nil
+	}
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	used := map[string]bool{}
+	var offset uintptr
+	for i, f := range rec.fields {
+		fsize, falign, err := g.sizeAlign(f.ty)
+		if err != nil {
+			return "", 0, 0, fmt.Errorf("field %s: %v", f.name, err)
+		}
+		ty, err := g.goType(f.ty, ctxField)
+		if err != nil {
+			return "", 0, 0, fmt.Errorf("field %s: %v", f.name, err)
+		}
+		if aligned := (offset + falign - 1) &^ (falign - 1); aligned > offset {
+			fmt.Fprintf(&b, "_ [%d]byte\n", aligned-offset)
+			offset = aligned
+		}
+		name := f.name
+		if name == "" {
+			name = fmt.Sprintf("Anon%d", i)
+		}
+		fmt.Fprintf(&b, "%s %s\n", unique(used, exportName(name)), ty)
+		offset += fsize
+		if falign > align {
+			align = falign
+		}
+	}
+	size = (offset + align - 1) &^ (align - 1)
+	if size > offset {
+		fmt.Fprintf(&b, "_ [%d]byte\n", size-offset)
+	}
+	b.WriteString("}")
+	if len(rec.fields) == 0 {
+		return "struct{}", 0, 1, nil
+	}
+	return b.String(), size, align, nil
+}
@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind uint8
+
+const (
+	tokIdent tokenKind = iota
+	tokNumber
+	tokString
+	tokChar
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  string // file:line for error messages
+}
+
+// puncts are the C punctuators ordered so that the longest match comes first.
+var puncts = []string{
+	"...", "<<=", ">>=",
+	"->", "++", "--", "<<", ">>", "<=", ">=", "==", "!=", "&&", "||",
+	"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "##",
+}
+
+// lex splits a line of C without comments into tokens.
+func lex(line, pos string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(line) && isIdentChar(line[j]) {
+				j++
+			}
+			if j < len(line) && (line[j] == '"' || line[j] == '\'') {
+				switch line[i:j] {
+				case "L", "u", "U", "u8":
+					// a prefixed string or character literal
+					i = j
+					continue
+				}
+			}
+			toks = append(toks, token{kind: tokIdent, text: line[i:j], pos: pos})
+			i = j
+		case isDigit(c) || (c == '.' && i+1 < len(line) && isDigit(line[i+1])):
+			j := i + 1
+			for j < len(line) {
+				if isIdentChar(line[j]) || line[j] == '.' {
+					j++
+					continue
+				}
+				if (line[j] == '+' || line[j] == '-') && strings.ContainsRune("eEpP", rune(line[j-1])) {
+					j++
+					continue
+				}
+				break
+			}
+			toks = append(toks, token{kind: tokNumber, text: line[i:j], pos: pos})
+			i = j
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(line) && line[j] != c {
+				if line[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(line) {
+				return nil, fmt.Errorf("%s: unterminated literal", pos)
+			}
+			kind := tokString
+			if c == '\'' {
+				kind = tokChar
+			}
+			toks = append(toks, token{kind: kind, text: line[i : j+1], pos: pos})
+			i = j + 1
+		default:
+			text := line[i : i+1]
+			for _, p := range puncts {
+				if strings.HasPrefix(line[i:], p) {
+					text = p
+					break
+				}
+			}
+			toks = append(toks, token{kind: tokPunct, text: text, pos: pos})
+			i += len(text)
+		}
+	}
+	return toks, nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// stripComments removes the comments of src and joins lines ending in a backslash.
+// The removed newlines are added after the joined line so that line numbers stay the same.
+func stripComments(src string) string {
+	var b strings.Builder
+	var pending int
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '\\' && strings.HasPrefix(src[i+1:], "\n"):
+			i++
+			pending++
+		case c == '\\' && strings.HasPrefix(src[i+1:], "\r\n"):
+			i += 2
+			pending++
+		case c == '/' && strings.HasPrefix(src[i+1:], "/"):
+			for i+1 < len(src) && src[i+1] != '\n' {
+				i++
+			}
+		case c == '/' && strings.HasPrefix(src[i+1:], "*"):
+			i += 2
+			for i+1 < len(src) && !(src[i] == '*' && src[i+1] == '/') {
+				if src[i] == '\n' {
+					pending++
+				}
+				i++
+			}
+			i++
+			b.WriteByte(' ')
+		case c == '"' || c == '\'':
+			b.WriteByte(c)
+			for i+1 < len(src) && src[i+1] != c && src[i+1] != '\n' {
+				i++
+				b.WriteByte(src[i])
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+					b.WriteByte(src[i])
+				}
+			}
+			if i+1 < len(src) && src[i+1] == c {
+				i++
+				b.WriteByte(c)
+			}
+		case c == '\n':
+			b.WriteString(strings.Repeat("\n", pending+1))
+			pending = 0
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,198 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+// Purego-bindgen generates Go bindings for the functions, types and constants of C headers
+// that call into a shared library with purego.
+//
+// Usage:
+//
+//	purego-bindgen [flags] header.h...
+//
+// For example, in a file of package zlib:
+//
+//	//go:generate go run github.com/ebitengine/purego/cmd/purego-bindgen -o zlib_bindings.go zlib.h
+//
+// The headers are run through a C preprocessor of its own. Quoted includes are followed and
+// translated as well while system includes like <stdint.h> are skipped since the parser knows
+// their types. Conditionals see the macros of the target given by -goos and -goarch.
+//
+// The output contains:
+//
+//   - a constant for every object-like macro that is a constant expression
+//   - a type and constants for every enum
+//   - a struct type for every C struct with explicit padding fields wherever C pads
+//   - a type for every typedef
+//   - a struct holding a func-typed field for every function with a purego tag naming its symbol
+//     and a Register method which calls purego.RegisterLibFunc for each of them
+//
+// The struct with the functions works with purego.RegisterLibFuncs too. const char * parameters
+// and results become strings and function pointers become uintptr for purego.NewCallback.
+// long becomes int64 on 64-bit Unix and int32 on Windows and 32-bit platforms. Since the types
+// and struct layouts depend on the target, the generated file names it and should only be built
+// for it. Declarations that can't be translated, like bit-fields, long double, unions with float
+// members passed by value or macros that aren't constants, are listed in comments.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+func main() {
+	var includes, defines listFlag
+	out := flag.String("o", "", "write the output to `file` instead of stdout")
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "the package `name` of the output (default $GOPACKAGE or main)")
+	lib := flag.String("type", "Lib", "the `name` of the struct holding the functions")
+	goos := flag.String("goos", envOr("GOOS", runtime.GOOS), "the GOOS of the target")
+	goarch := flag.String("goarch", envOr("GOARCH", runtime.GOARCH), "the GOARCH of the target")
+	flag.Var(&includes, "I", "add `dir` to the directories searched for quoted includes")
+	flag.Var(&defines, "D", "define the macro `name[=value]`")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: purego-bindgen [flags] header.h...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *pkg == "" {
+		*pkg = "main"
+	}
+	src, err := bindgen(config{
+		headers:  flag.Args(),
+		includes: includes,
+		defines:  defines,
+		pkg:      *pkg,
+		lib:      *lib,
+		goos:     *goos,
+		goarch:   *goarch,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "purego-bindgen: %v\n", err)
+		os.Exit(1)
+	}
+	if *out == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "purego-bindgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+type config struct {
+	headers  []string
+	includes []string
+	defines  []string
+	pkg      string
+	lib      string
+	goos     string
+	goarch   string
+}
+
+func bindgen(c config) ([]byte, error) {
+	pp := newPreprocessor(c.includes)
+	for _, def := range append(predefined(c.goos, c.goarch), c.defines...) {
+		if err := pp.define(def); err != nil {
+			return nil, err
+		}
+	}
+	var sources []string
+	for _, h := range c.headers {
+		if err := pp.file(h); err != nil {
+			return nil, err
+		}
+		sources = append(sources, filepath.Base(h))
+	}
+	t := target{ptrSize: 8, align64: 8}
+	switch c.goarch {
+	case "386", "arm", "mips", "mipsle":
+		t.ptrSize = 4
+	}
+	if c.goarch == "386" && c.goos != "windows" {
+		t.align64 = 4
+	}
+	p := newParser(pp.tokens)
+	if c.goos == "windows" || t.ptrSize == 4 {
+		p.long = "int32"
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	g := &generator{p: p, pp: pp, target: t, platform: c.goos + "/" + c.goarch, pkg: c.pkg, lib: c.lib, sources: sources}
+	return g.generate()
+}
+
+// predefined returns the macros a C compiler for goos and goarch defines.
+func predefined(goos, goarch string) []string {
+	defs := []string{"__STDC__", "__STDC_VERSION__=201112L", "__GNUC__=4"}
+	switch goos {
+	case "linux", "android":
+		defs = append(defs, "__linux__", "__linux", "__unix__", "__unix")
+	case "darwin", "ios":
+		defs = append(defs, "__APPLE__", "__MACH__")
+	case "freebsd":
+		defs = append(defs, "__FreeBSD__", "__unix__", "__unix")
+	case "netbsd":
+		defs = append(defs, "__NetBSD__", "__unix__", "__unix")
+	case "windows":
+		defs = append(defs, "_WIN32")
+	}
+	switch goarch {
+	case "amd64":
+		defs = append(defs, "__x86_64__", "__x86_64", "__amd64__", "__amd64")
+	case "arm64":
+		defs = append(defs, "__aarch64__")
+		if goos == "darwin" || goos == "ios" {
+			defs = append(defs, "__arm64__")
+		}
+	case "loong64":
+		defs = append(defs, "__loongarch__", "__loongarch64")
+	case "386":
+		defs = append(defs, "__i386__", "__i386")
+	case "arm":
+		defs = append(defs, "__arm__")
+	case "riscv64":
+		defs = append(defs, "__riscv")
+	case "ppc64le":
+		defs = append(defs, "__powerpc64__")
+	case "s390x":
+		defs = append(defs, "__s390x__")
+	}
+	switch goarch {
+	case "386", "arm", "mips", "mipsle":
+		defs = append(defs, "__SIZEOF_POINTER__=4")
+	default:
+		defs = append(defs, "__SIZEOF_POINTER__=8")
+		if goos == "windows" {
+			defs = append(defs, "_WIN64")
+		} else {
+			defs = append(defs, "__LP64__", "_LP64")
+		}
+	}
+	return defs
+}
@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"go/ast"
+	"go/importer"
+	goparser "go/parser"
+	gotoken "go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		header, pkg string
+	}{
+		{"example.h", "example"},
+		// a header without functions doesn't need purego
+		{"constants.h", "constants"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pkg, func(t *testing.T) {
+			src, err := bindgen(config{
+				headers: []string{filepath.Join("testdata", tt.header)},
+				pkg:     tt.pkg,
+				lib:     "Lib",
+				goos:    "linux",
+				goarch:  "amd64",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", tt.pkg+".go.golden")
+			if *update {
+				if err := os.WriteFile(golden, src, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(src, want) {
+				t.Errorf("bindgen output differs from %s; run go test -update to update it:\n%s", golden, src)
+			}
+			typeCheck(t, tt.pkg+".go", src)
+		})
+	}
+}
+
+// TestTestdata generates bindings for the C sources the purego tests use.
+func TestTestdata(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "..", "testdata", "*", "*.c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		for _, goarch := range []string{"amd64", "arm64", "386"} {
+			src, err := bindgen(config{headers: []string{file}, pkg: "main", lib: "Lib", goos: "linux", goarch: goarch})
+			if err != nil {
+				t.Errorf("%s on %s: %v", file, goarch, err)
+				continue
+			}
+			typeCheck(t, filepath.Base(file)+".go", src)
+		}
+	}
+}
+
+// TestTarget checks the declarations whose translation depends on the target.
+func TestTarget(t *testing.T) {
+	header := filepath.Join(t.TempDir(), "target.h")
+	const src = `typedef union floats { float f; double d; } floats;
+typedef struct wrapper { floats u; } wrapper;
+long ticks(unsigned long n);
+floats get_floats(void);
+void set_wrapper(wrapper w);
+void set_floats(const floats *f);
+`
+	if err := os.WriteFile(header, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		goos, goarch, long string
+	}{
+		{"linux", "amd64", "int64"},
+		{"darwin", "arm64", "int64"},
+		{"linux", "386", "int32"},
+		{"windows", "amd64", "int32"},
+	}
+	for _, tt := range tests {
+		src, err := bindgen(config{headers: []string{header}, pkg: "target", lib: "Lib", goos: tt.goos, goarch: tt.goarch})
+		if err != nil {
+			t.Fatalf("%s/%s: %v", tt.goos, tt.goarch, err)
+		}
+		// the fields are aligned by gofmt
+		fields := strings.Join(strings.Fields(string(src)), " ")
+		for _, want := range []string{
+			"for " + tt.goos + "/" + tt.goarch + ". DO NOT EDIT.",
+			"Ticks func(n u" + tt.long + ") " + tt.long,
+			"Set_floats func(f *Floats)",
+			"// get_floats is skipped: result: a union with float members can't be passed by value",
+			"// set_wrapper is skipped: parameter w: a union with float members can't be passed by value",
+		} {
+			if !strings.Contains(fields, want) {
+				t.Errorf("%s/%s: the bindings don't contain %q:\n%s", tt.goos, tt.goarch, want, src)
+			}
+		}
+		typeCheck(t, "target.go", src)
+	}
+}
+
+// puregoStub declares the API of purego that generated code uses.
+const puregoStub = `package purego
+
+type Variadic struct{}
+
+func RegisterLibFunc(fptr any, handle uintptr, name string) {}
+`
+
+type stubImporter struct {
+	purego *types.Package
+}
+
+func (s stubImporter) Import(path string) (*types.Package, error) {
+	if path == "github.com/ebitengine/purego" {
+		return s.purego, nil
+	}
+	return importer.Default().Import(path)
+}
+
+func typeCheck(t *testing.T, name string, src []byte) {
+	t.Helper()
+	check := func(path, name string, src []byte, imp types.Importer) *types.Package {
+		fset := gotoken.NewFileSet()
+		f, err := goparser.ParseFile(fset, name, src, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		conf := types.Config{Importer: imp}
+		pkg, err := conf.Check(path, fset, []*ast.File{f}, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return pkg
+	}
+	purego := check("github.com/ebitengine/purego", "purego.go", []byte(puregoStub), nil)
+	check("example", name, src, stubImporter{purego})
+}
@@ -0,0 +1,747 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type typeKind uint8
+
+const (
+	kindVoid typeKind = iota
+	kindPrim
+	kindPointer
+	kindArray
+	kindFunc
+	kindRecord
+	kindEnum
+	kindNamed
+)
+
+// cType is a C type. Types that can't be translated are recorded with the reason
+// in err and only reported when a declaration uses them.
+type cType struct {
+	kind     typeKind
+	name     string // the Go type of a primitive or the name of a typedef
+	isConst  bool
+	elem     *cType // the element of a pointer or array and the result of a function
+	n        int    // the length of an array or -1
+	params   []param
+	variadic bool
+	rec      *record // the struct, union or enum
+	err      string
+}
+
+type param struct {
+	name string
+	ty   *cType
+}
+
+// record is a struct, union or enum.
+type record struct {
+	keyword     string // struct, union or enum
+	tag         string
+	typedef     string // the first typedef naming the record
+	complete    bool
+	fields      []param
+	enumerators []enumerator
+	err         string
+}
+
+type enumerator struct {
+	name  string
+	value []token // nil if it is one more than the previous one
+}
+
+type typedef struct {
+	name string
+	ty   *cType
+}
+
+type function struct {
+	name string
+	ty   *cType
+	pos  string
+}
+
+// builtinTypes are the types of the system headers that are skipped by the preprocessor.
+// size_t is uintptr which follows the pointer size. The size of long is set by the target.
+var builtinTypes = map[string]*cType{
+	"int8_t":    prim("int8"),
+	"int16_t":   prim("int16"),
+	"int32_t":   prim("int32"),
+	"int64_t":   prim("int64"),
+	"uint8_t":   prim("uint8"),
+	"uint16_t":  prim("uint16"),
+	"uint32_t":  prim("uint32"),
+	"uint64_t":  prim("uint64"),
+	"intptr_t":  prim("int"),
+	"uintptr_t": prim("uintptr"),
+	"ssize_t":   prim("int"),
+	"ptrdiff_t": prim("int"),
+	"size_t":    prim("uintptr"),
+	"off_t":     prim("int64"),
+	"wchar_t":   prim("int32"),
+	"char16_t":  prim("uint16"),
+	"char32_t":  prim("uint32"),
+	"bool":      prim("bool"),
+	"FILE":      {kind: kindRecord, rec: &record{keyword: "struct", tag: "FILE"}},
+	"va_list":   {kind: kindPrim, err: "va_list is not supported"},
+}
+
+func prim(name string) *cType {
+	return &cType{kind: kindPrim, name: name}
+}
+
+// ignoredWords are qualifiers and attributes that don't change how a declaration is called.
+var ignoredWords = map[string]bool{
+	"volatile": true, "restrict": true, "__restrict": true, "__restrict__": true, "register": true,
+	"inline": true, "__inline": true, "__inline__": true, "_Noreturn": true, "__extension__": true,
+	"__cdecl": true, "__stdcall": true, "__fastcall": true, "auto": true, "_Thread_local": true,
+	"__const": true,
+}
+
+// ignoredCalls are attributes that are followed by parenthesized arguments.
+var ignoredCalls = map[string]bool{
+	"__attribute__": true, "__attribute": true, "__declspec": true, "__asm__": true, "__asm": true,
+	"asm": true, "_Alignas": true, "alignas": true, "__nonnull": true, "_Static_assert": true, "static_assert": true,
+}
+
+type parser struct {
+	toks      []token
+	i         int
+	typedefs  map[string]*cType
+	records   map[string]*record // by keyword and tag
+	enumConst map[string]int64
+	enumNames map[string]bool
+	long      string // the Go type of long
+
+	// the declarations in the order they appear
+	recordList  []*record
+	typedefList []typedef
+	funcs       []function
+	funcNames   map[string]bool
+}
+
+func newParser(toks []token) *parser {
+	return &parser{
+		toks:      toks,
+		typedefs:  map[string]*cType{},
+		records:   map[string]*record{},
+		enumConst: map[string]int64{},
+		enumNames: map[string]bool{},
+		funcNames: map[string]bool{},
+		long:      "int64",
+	}
+}
+
+func (p *parser) peek() token {
+	if p.i < len(p.toks) {
+		return p.toks[p.i]
+	}
+	return token{kind: tokPunct}
+}
+
+func (p *parser) peekAt(n int) token {
+	if p.i+n < len(p.toks) {
+		return p.toks[p.i+n]
+	}
+	return token{kind: tokPunct}
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.i++
+	return t
+}
+
+func (p *parser) accept(text string) bool {
+	if p.i < len(p.toks) && p.toks[p.i].text == text && p.toks[p.i].kind != tokString {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return p.errorf("expected %q but found %q", text, p.peek().text)
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	pos := "end of input"
+	if p.i < len(p.toks) {
+		pos = p.toks[p.i].pos
+	} else if len(p.toks) > 0 {
+		pos = p.toks[len(p.toks)-1].pos
+	}
+	return fmt.Errorf("%s: %s", pos, fmt.Sprintf(format, args...))
+}
+
+// skipBalanced skips the tokens from an opening bracket to its closing one.
+func (p *parser) skipBalanced() error {
+	open := p.next().text
+	close := map[string]string{"(": ")", "{": "}", "[": "]"}[open]
+	depth := 1
+	for p.i < len(p.toks) {
+		switch t := p.next(); t.text {
+		case open:
+			depth++
+		case close:
+			if depth--; depth == 0 {
+				return nil
+			}
+		}
+	}
+	return p.errorf("missing %q", close)
+}
+
+// skipIgnored skips qualifiers and attributes.
+func (p *parser) skipIgnored() error {
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokIdent && ignoredWords[t.text]:
+			p.i++
+		case t.kind == tokIdent && ignoredCalls[t.text] && p.peekAt(1).text == "(":
+			p.i++
+			if err := p.skipBalanced(); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+func (p *parser) parse() error {
+	externC := 0
+	for p.i < len(p.toks) {
+		switch t := p.peek(); {
+		case t.text == ";":
+			p.i++
+		case t.text == "extern" && p.peekAt(1).kind == tokString:
+			p.i += 2
+			if p.accept("{") {
+				externC++
+			}
+		case t.text == "}" && externC > 0:
+			p.i++
+			externC--
+		case t.text == "_Static_assert" || t.text == "static_assert":
+			p.i++
+			if err := p.skipBalanced(); err != nil {
+				return err
+			}
+		default:
+			if err := p.declaration(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// declaration parses a declaration or a function definition at the top level.
+func (p *parser) declaration() error {
+	var isTypedef, isStatic bool
+	base, err := p.specifiers(func(word string) bool {
+		switch word {
+		case "typedef":
+			isTypedef = true
+		case "static":
+			isStatic = true
+		case "extern":
+		default:
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if p.accept(";") {
+		return nil
+	}
+	for {
+		start := p.peek().pos
+		name, ty, err := p.declarator(base)
+		if err != nil {
+			return err
+		}
+		if err := p.skipIgnored(); err != nil {
+			return err
+		}
+		switch {
+		case isTypedef:
+			p.addTypedef(name, ty)
+		case ty.kind == kindFunc && !isStatic && name != "":
+			if !p.funcNames[name] {
+				p.funcNames[name] = true
+				p.funcs = append(p.funcs, function{name: name, ty: ty, pos: start})
+			}
+		}
+		if ty.kind == kindFunc && p.peek().text == "{" {
+			// a function definition
+			return p.skipBalanced()
+		}
+		if p.accept("=") {
+			// an initializer of a variable
+			for p.i < len(p.toks) && p.peek().text != "," && p.peek().text != ";" {
+				if t := p.peek().text; t == "{" || t == "(" {
+					if err := p.skipBalanced(); err != nil {
+						return err
+					}
+					continue
+				}
+				p.i++
+			}
+		}
+		if p.accept(";") {
+			return nil
+		}
+		if err := p.expect(","); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *parser) addTypedef(name string, ty *cType) {
+	if name == "" {
+		return
+	}
+	if old, ok := p.typedefs[name]; ok && old != nil {
+		// a repeated typedef of the same type
+		return
+	}
+	if (ty.kind == kindRecord || ty.kind == kindEnum) && ty.rec.typedef == "" {
+		ty.rec.typedef = name
+	}
+	p.typedefs[name] = ty
+	p.typedefList = append(p.typedefList, typedef{name: name, ty: ty})
+}
+
+// specifiers parses the declaration specifiers and returns the base type of the declarators.
+// storage is called for the words that aren't type specifiers and reports whether it consumed them.
+func (p *parser) specifiers(storage func(word string) bool) (*cType, error) {
+	var (
+		signed, unsigned, short, complex bool
+		longs                            int
+		base                             string
+		ty                               *cType
+		isConst                          bool
+	)
+loop:
+	for {
+		if err := p.skipIgnored(); err != nil {
+			return nil, err
+		}
+		t := p.peek()
+		if t.kind != tokIdent {
+			break
+		}
+		if storage != nil && storage(t.text) {
+			p.i++
+			continue
+		}
+		switch t.text {
+		case "const":
+			isConst = true
+		case "signed", "__signed", "__signed__":
+			signed = true
+		case "unsigned":
+			unsigned = true
+		case "short":
+			short = true
+		case "long":
+			longs++
+		case "_Complex", "__complex__":
+			complex = true
+		case "int", "char", "float", "double", "void", "_Bool":
+			base = t.text
+		case "struct", "union", "enum":
+			if ty != nil || base != "" {
+				return nil, p.errorf("unexpected %s", t.text)
+			}
+			var err error
+			if ty, err = p.record(); err != nil {
+				return nil, err
+			}
+			continue
+		default:
+			if ty != nil || base != "" || signed || unsigned || short || longs > 0 {
+				// the name of the declarator
+				break loop
+			}
+			if td, ok := p.typedefs[t.text]; ok {
+				ty = &cType{kind: kindNamed, name: t.text, elem: td}
+			} else if b, ok := builtinTypes[t.text]; ok {
+				ty = b
+			} else {
+				ty = &cType{kind: kindPrim, err: fmt.Sprintf("unknown type %s", t.text)}
+			}
+		}
+		p.i++
+	}
+	if ty == nil {
+		ty = &cType{kind: kindPrim}
+		switch {
+		case base == "void":
+			ty.kind = kindVoid
+		case base == "_Bool":
+			ty.name = "bool"
+		case base == "char" && signed:
+			ty.name = "int8"
+		case base == "char" && unsigned:
+			ty.name = "uint8"
+		case base == "char":
+			ty.name = "byte"
+		case base == "float" && complex:
+			ty.name = "complex64"
+		case base == "float":
+			ty.name = "float32"
+		case base == "double" && complex:
+			ty.name = "complex128"
+		case base == "double" && longs > 0:
+			ty.err = "long double is not supported"
+		case base == "double":
+			ty.name = "float64"
+		case short:
+			ty.name = "int16"
+		case longs > 1:
+			ty.name = "int64"
+		case longs == 1:
+			ty.name = p.long
+		case base == "int" || signed || unsigned:
+			ty.name = "int32"
+		default:
+			return nil, p.errorf("expected a type but found %q", p.peek().text)
+		}
+		if unsigned && ty.name != "byte" && ty.name != "uint8" {
+			ty.name = "u" + ty.name
+		}
+	}
+	if isConst {
+		c := *ty
+		c.isConst = true
+		ty = &c
+	}
+	return ty, nil
+}
+
+// record parses a struct, union or enum specifier.
+func (p *parser) record() (*cType, error) {
+	keyword := p.next().text
+	if err := p.skipIgnored(); err != nil {
+		return nil, err
+	}
+	var tag string
+	if p.peek().kind == tokIdent {
+		tag = p.next().text
+	}
+	if err := p.skipIgnored(); err != nil {
+		return nil, err
+	}
+	var rec *record
+	if tag != "" {
+		rec = p.records[keyword+" "+tag]
+	}
+	if rec == nil || (p.peek().text == "{" && rec.complete) {
+		rec = &record{keyword: keyword, tag: tag}
+		if tag != "" {
+			p.records[keyword+" "+tag] = rec
+		}
+		p.recordList = append(p.recordList, rec)
+	}
+	kind := kindRecord
+	if keyword == "enum" {
+		kind = kindEnum
+	}
+	if !p.accept("{") {
+		if tag == "" {
+			return nil, p.errorf("%s without a name or body", keyword)
+		}
+		return &cType{kind: kind, rec: rec}, nil
+	}
+	rec.complete = true
+	if keyword == "enum" {
+		if err := p.enumBody(rec); err != nil {
+			return nil, err
+		}
+	} else if err := p.fieldList(rec); err != nil {
+		return nil, err
+	}
+	return &cType{kind: kind, rec: rec}, nil
+}
+
+func (p *parser) enumBody(rec *record) error {
+	next := int64(0)
+	for !p.accept("}") {
+		t := p.next()
+		if t.kind != tokIdent {
+			return p.errorf("expected an enumerator but found %q", t.text)
+		}
+		if err := p.skipIgnored(); err != nil {
+			return err
+		}
+		e := enumerator{name: t.text}
+		if p.accept("=") {
+			for p.i < len(p.toks) && p.peek().text != "," && p.peek().text != "}" {
+				if p.peek().text == "(" {
+					start := p.i
+					if err := p.skipBalanced(); err != nil {
+						return err
+					}
+					e.value = append(e.value, p.toks[start:p.i]...)
+					continue
+				}
+				e.value = append(e.value, p.next())
+			}
+			v, err := evalConst(e.value, p.lookupConst)
+			if err != nil {
+				rec.err = fmt.Sprintf("value of %s: %v", e.name, err)
+			}
+			next = v
+		}
+		p.enumConst[e.name] = next
+		p.enumNames[e.name] = true
+		next++
+		rec.enumerators = append(rec.enumerators, e)
+		if !p.accept(",") {
+			if err := p.expect("}"); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return nil
+}
+
+func (p *parser) lookupConst(name string) (int64, bool) {
+	v, ok := p.enumConst[name]
+	return v, ok
+}
+
+func (p *parser) fieldList(rec *record) error {
+	for !p.accept("}") {
+		if p.i >= len(p.toks) {
+			return p.errorf("missing } of %s", rec.keyword)
+		}
+		if p.accept(";") {
+			continue
+		}
+		base, err := p.specifiers(nil)
+		if err != nil {
+			return err
+		}
+		if p.accept(";") {
+			// an anonymous struct or union whose fields are promoted
+			rec.fields = append(rec.fields, param{ty: base})
+			continue
+		}
+		for {
+			name, ty, err := p.declarator(base)
+			if err != nil {
+				return err
+			}
+			if p.accept(":") {
+				for p.peek().text != "," && p.peek().text != ";" && p.i < len(p.toks) {
+					p.i++
+				}
+				rec.err = fmt.Sprintf("bit-field %s is not supported", name)
+			}
+			if err := p.skipIgnored(); err != nil {
+				return err
+			}
+			rec.fields = append(rec.fields, param{name: name, ty: ty})
+			if p.accept(";") {
+				break
+			}
+			if err := p.expect(","); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// declarator parses a possibly abstract declarator of the type base.
+func (p *parser) declarator(base *cType) (string, *cType, error) {
+	if err := p.skipIgnored(); err != nil {
+		return "", nil, err
+	}
+	for p.accept("*") {
+		base = &cType{kind: kindPointer, elem: base}
+		for {
+			if err := p.skipIgnored(); err != nil {
+				return "", nil, err
+			}
+			if !p.accept("const") {
+				break
+			}
+			c := *base
+			c.isConst = true
+			base = &c
+		}
+	}
+	if p.peek().text == "(" && p.isNestedDeclarator() {
+		p.i++
+		// the inner declarator applies to the type the suffixes create
+		hole := &cType{}
+		name, inner, err := p.declarator(hole)
+		if err != nil {
+			return "", nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return "", nil, err
+		}
+		outer, err := p.suffixes(base)
+		if err != nil {
+			return "", nil, err
+		}
+		*hole = *outer
+		return name, inner, nil
+	}
+	var name string
+	if t := p.peek(); t.kind == tokIdent && !ignoredCalls[t.text] {
+		name = p.next().text
+	}
+	ty, err := p.suffixes(base)
+	return name, ty, err
+}
+
+// isNestedDeclarator reports whether the parenthesis at the current token
+// starts a nested declarator and not a parameter list.
+func (p *parser) isNestedDeclarator() bool {
+	switch t := p.peekAt(1); {
+	case t.text == "*" || t.text == "^":
+		return true
+	case t.text == "(":
+		return true
+	case t.kind == tokIdent:
+		if ignoredCalls[t.text] || ignoredWords[t.text] {
+			return true
+		}
+		_, isType := p.typedefs[t.text]
+		_, isBuiltin := builtinTypes[t.text]
+		switch t.text {
+		case "void", "char", "short", "int", "long", "float", "double", "signed", "unsigned",
+			"_Bool", "const", "struct", "union", "enum", "_Complex":
+			return false
+		}
+		return !isType && !isBuiltin && p.peekAt(2).text == ")"
+	}
+	return false
+}
+
+// suffixes parses the array and function suffixes of a declarator.
+func (p *parser) suffixes(base *cType) (*cType, error) {
+	var dims []*cType
+	for {
+		switch {
+		case p.accept("["):
+			var expr []token
+			for p.i < len(p.toks) && p.peek().text != "]" {
+				if t := p.peek().text; t == "static" || t == "const" || ignoredWords[t] {
+					p.i++
+					continue
+				}
+				expr = append(expr, p.next())
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			arr := &cType{kind: kindArray, n: -1}
+			if len(expr) > 0 {
+				n, err := evalConst(expr, p.lookupConst)
+				if err != nil {
+					arr.err = fmt.Sprintf("array length: %v", err)
+				}
+				arr.n = int(n)
+			}
+			dims = append(dims, arr)
+		case p.peek().text == "(":
+			p.i++
+			fn, err := p.params()
+			if err != nil {
+				return nil, err
+			}
+			dims = append(dims, fn)
+		default:
+			// int a[2][3] is an array of 2 arrays of 3 ints
+			ty := base
+			for i := len(dims) - 1; i >= 0; i-- {
+				dims[i].elem = ty
+				ty = dims[i]
+			}
+			return ty, nil
+		}
+	}
+}
+
+// params parses a parameter list after its opening parenthesis.
+func (p *parser) params() (*cType, error) {
+	fn := &cType{kind: kindFunc}
+	if p.accept(")") {
+		return fn, nil
+	}
+	if p.peek().text == "void" && p.peekAt(1).text == ")" {
+		p.i += 2
+		return fn, nil
+	}
+	for {
+		if p.accept("...") {
+			fn.variadic = true
+			return fn, p.expect(")")
+		}
+		base, err := p.specifiers(func(word string) bool { return word == "register" })
+		if err != nil {
+			return nil, err
+		}
+		name, ty, err := p.declarator(base)
+		if err != nil {
+			return nil, err
+		}
+		fn.params = append(fn.params, param{name: name, ty: ty})
+		if p.accept(")") {
+			return fn, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// String returns the C spelling of ty for comments.
+func (ty *cType) String() string {
+	var b strings.Builder
+	ty.write(&b)
+	return b.String()
+}
+
+func (ty *cType) write(b *strings.Builder) {
+	switch ty.kind {
+	case kindVoid:
+		b.WriteString("void")
+	case kindPrim:
+		b.WriteString(ty.name)
+	case kindNamed:
+		b.WriteString(ty.name)
+	case kindRecord, kindEnum:
+		b.WriteString(ty.rec.keyword + " " + ty.rec.tag)
+	case kindPointer:
+		ty.elem.write(b)
+		b.WriteString("*")
+	case kindArray:
+		ty.elem.write(b)
+		fmt.Fprintf(b, "[%d]", ty.n)
+	case kindFunc:
+		ty.elem.write(b)
+		b.WriteString("(...)")
+	}
+}
@@ -0,0 +1,575 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type macro struct {
+	name     string
+	function bool
+	params   []string
+	variadic bool
+	body     []token
+	user     bool // defined in a header that is translated
+}
+
+// preprocessor runs the C preprocessor over headers. Quoted includes are followed
+// while system includes are skipped as their types are built into the parser.
+type preprocessor struct {
+	macros   map[string]*macro
+	defines  []*macro // the object-like macros of the translated headers in order
+	includes []string // the directories searched for quoted includes
+	tokens   []token
+	depth    int
+}
+
+func newPreprocessor(includes []string) *preprocessor {
+	return &preprocessor{macros: map[string]*macro{}, includes: includes}
+}
+
+// define adds a macro given as NAME or NAME=VALUE on the command line.
+func (p *preprocessor) define(def string) error {
+	name, value, ok := strings.Cut(def, "=")
+	if !ok {
+		value = "1"
+	}
+	body, err := lex(value, "-D "+name)
+	if err != nil {
+		return err
+	}
+	p.macros[name] = &macro{name: name, body: body}
+	return nil
+}
+
+type cond struct {
+	active bool // the current branch is included
+	taken  bool // a branch of this conditional has been included
+	parent bool // the enclosing conditional is active
+}
+
+func (p *preprocessor) file(path string) error {
+	if p.depth > 200 {
+		return fmt.Errorf("%s: #include nested too deeply", path)
+	}
+	p.depth++
+	defer func() { p.depth-- }()
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var conds []cond
+	active := func() bool {
+		return len(conds) == 0 || conds[len(conds)-1].active
+	}
+	var pending []token
+	flush := func() {
+		p.tokens = append(p.tokens, p.expand(pending, nil)...)
+		pending = nil
+	}
+	for n, line := range strings.Split(stripComments(string(src)), "\n") {
+		pos := fmt.Sprintf("%s:%d", path, n+1)
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "#") {
+			if !active() {
+				continue
+			}
+			toks, err := lex(line, pos)
+			if err != nil {
+				return err
+			}
+			pending = append(pending, toks...)
+			continue
+		}
+		directive := strings.TrimSpace(trimmed[1:])
+		name := directive
+		rest := ""
+		if i := strings.IndexFunc(directive, func(r rune) bool { return !isIdentChar(byte(r)) || r > 0x7f }); i >= 0 {
+			name, rest = directive[:i], strings.TrimSpace(directive[i:])
+		}
+		switch name {
+		case "if", "ifdef", "ifndef":
+			c := cond{parent: active()}
+			if c.parent {
+				ok, err := p.condition(name, rest, pos)
+				if err != nil {
+					return err
+				}
+				c.active, c.taken = ok, ok
+			}
+			conds = append(conds, c)
+		case "elif", "else":
+			if len(conds) == 0 {
+				return fmt.Errorf("%s: #%s without #if", pos, name)
+			}
+			c := &conds[len(conds)-1]
+			c.active = false
+			if c.parent && !c.taken {
+				ok := true
+				if name == "elif" {
+					var err error
+					if ok, err = p.condition("if", rest, pos); err != nil {
+						return err
+					}
+				}
+				c.active, c.taken = ok, ok
+			}
+		case "endif":
+			if len(conds) == 0 {
+				return fmt.Errorf("%s: #endif without #if", pos)
+			}
+			conds = conds[:len(conds)-1]
+		default:
+			if !active() {
+				continue
+			}
+			flush()
+			if err := p.directive(name, rest, path, pos); err != nil {
+				return err
+			}
+		}
+	}
+	if len(conds) > 0 {
+		return fmt.Errorf("%s: missing #endif", path)
+	}
+	flush()
+	return nil
+}
+
+func (p *preprocessor) directive(name, rest, path, pos string) error {
+	switch name {
+	case "define":
+		toks, err := lex(rest, pos)
+		if err != nil {
+			return err
+		}
+		if len(toks) == 0 || toks[0].kind != tokIdent {
+			return fmt.Errorf("%s: malformed #define", pos)
+		}
+		m := &macro{name: toks[0].text, user: true}
+		body := toks[1:]
+		if strings.HasPrefix(rest[len(m.name):], "(") {
+			// a function-like macro
+			m.function = true
+			i := 2
+			for ; i < len(toks) && toks[i].text != ")"; i++ {
+				switch t := toks[i]; {
+				case t.text == ",":
+				case t.text == "...":
+					m.variadic = true
+				case t.kind == tokIdent:
+					m.params = append(m.params, t.text)
+				default:
+					return fmt.Errorf("%s: malformed parameters of macro %s", pos, m.name)
+				}
+			}
+			if i >= len(toks) {
+				return fmt.Errorf("%s: malformed parameters of macro %s", pos, m.name)
+			}
+			body = toks[i+1:]
+		}
+		m.body = body
+		if _, ok := p.macros[m.name]; !ok && !m.function {
+			p.defines = append(p.defines, m)
+		}
+		p.macros[m.name] = m
+	case "undef":
+		delete(p.macros, strings.TrimSpace(rest))
+	case "include", "include_next", "import":
+		if !strings.HasPrefix(rest, `"`) {
+			// system headers are not translated
+			return nil
+		}
+		file := strings.Trim(rest, `"`)
+		dirs := append([]string{filepath.Dir(path)}, p.includes...)
+		for _, dir := range dirs {
+			candidate := filepath.Join(dir, file)
+			if _, err := os.Stat(candidate); err == nil {
+				return p.file(candidate)
+			}
+		}
+		return fmt.Errorf("%s: can't find include %q", pos, file)
+	case "error":
+		return fmt.Errorf("%s: #error %s", pos, rest)
+	}
+	// #pragma, #line, #warning and the null directive are ignored
+	return nil
+}
+
+// condition evaluates the condition of #if, #ifdef or #ifndef.
+func (p *preprocessor) condition(kind, expr, pos string) (bool, error) {
+	switch kind {
+	case "ifdef":
+		_, ok := p.macros[strings.TrimSpace(expr)]
+		return ok, nil
+	case "ifndef":
+		_, ok := p.macros[strings.TrimSpace(expr)]
+		return !ok, nil
+	}
+	toks, err := lex(expr, pos)
+	if err != nil {
+		return false, err
+	}
+	// defined is evaluated before the macros are expanded
+	var resolved []token
+	for i := 0; i < len(toks); i++ {
+		if toks[i].text != "defined" {
+			resolved = append(resolved, toks[i])
+			continue
+		}
+		var name string
+		switch {
+		case i+3 < len(toks) && toks[i+1].text == "(" && toks[i+3].text == ")":
+			name = toks[i+2].text
+			i += 3
+		case i+1 < len(toks):
+			name = toks[i+1].text
+			i++
+		default:
+			return false, fmt.Errorf("%s: malformed defined", pos)
+		}
+		value := "0"
+		if _, ok := p.macros[name]; ok {
+			value = "1"
+		}
+		resolved = append(resolved, token{kind: tokNumber, text: value, pos: pos})
+	}
+	v, err := evalConst(p.expand(resolved, nil), nil)
+	if err != nil {
+		return false, fmt.Errorf("%s: %v", pos, err)
+	}
+	return v != 0, nil
+}
+
+// expand replaces the macros in toks. The macros in hide are not expanded
+// to stop the recursion of a macro that refers to itself.
+func (p *preprocessor) expand(toks []token, hide map[string]bool) []token {
+	var out []token
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		m, ok := p.macros[t.text]
+		if t.kind != tokIdent || !ok || hide[t.text] {
+			out = append(out, t)
+			continue
+		}
+		inner := map[string]bool{m.name: true}
+		for name := range hide {
+			inner[name] = true
+		}
+		if !m.function {
+			out = append(out, p.expand(m.body, inner)...)
+			continue
+		}
+		args, end, ok := macroArgs(toks, i+1)
+		if !ok {
+			// a function-like macro name that isn't called
+			out = append(out, t)
+			continue
+		}
+		out = append(out, p.expand(p.substitute(m, args, hide), inner)...)
+		i = end
+	}
+	return out
+}
+
+// macroArgs returns the arguments of a macro call whose parenthesis is at toks[i]
+// and the index of the closing parenthesis.
+func macroArgs(toks []token, i int) (args [][]token, end int, ok bool) {
+	if i >= len(toks) || toks[i].text != "(" {
+		return nil, 0, false
+	}
+	var arg []token
+	depth := 0
+	for j := i + 1; j < len(toks); j++ {
+		switch toks[j].text {
+		case "(":
+			depth++
+		case ")":
+			if depth == 0 {
+				if len(arg) > 0 || len(args) > 0 {
+					args = append(args, arg)
+				}
+				return args, j, true
+			}
+			depth--
+		case ",":
+			if depth == 0 {
+				args = append(args, arg)
+				arg = nil
+				continue
+			}
+		}
+		arg = append(arg, toks[j])
+	}
+	return nil, 0, false
+}
+
+// substitute replaces the parameters in the body of m with args.
+func (p *preprocessor) substitute(m *macro, args [][]token, hide map[string]bool) []token {
+	param := func(name string) ([]token, bool) {
+		if name == "__VA_ARGS__" && m.variadic {
+			var va []token
+			for i := len(m.params); i < len(args); i++ {
+				if i > len(m.params) {
+					va = append(va, token{kind: tokPunct, text: ","})
+				}
+				va = append(va, args[i]...)
+			}
+			return va, true
+		}
+		for i, pname := range m.params {
+			if pname == name {
+				if i < len(args) {
+					return args[i], true
+				}
+				return nil, true
+			}
+		}
+		return nil, false
+	}
+	var out []token
+	for i := 0; i < len(m.body); i++ {
+		t := m.body[i]
+		switch {
+		case t.text == "#" && i+1 < len(m.body):
+			if arg, ok := param(m.body[i+1].text); ok {
+				var s []string
+				for _, a := range arg {
+					s = append(s, a.text)
+				}
+				out = append(out, token{kind: tokString, text: strconv.Quote(strings.Join(s, " ")), pos: t.pos})
+				i++
+				continue
+			}
+		case t.text == "##" && len(out) > 0 && i+1 < len(m.body):
+			next := []token{m.body[i+1]}
+			if arg, ok := param(m.body[i+1].text); ok {
+				next = arg
+			}
+			i++
+			if len(next) == 0 {
+				continue
+			}
+			pasted, err := lex(out[len(out)-1].text+next[0].text, t.pos)
+			if err != nil {
+				continue
+			}
+			out = append(append(out[:len(out)-1], pasted...), next[1:]...)
+			continue
+		}
+		if arg, ok := param(t.text); ok && t.kind == tokIdent {
+			if i+1 < len(m.body) && m.body[i+1].text == "##" {
+				out = append(out, arg...)
+			} else {
+				out = append(out, p.expand(arg, hide)...)
+			}
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// evalConst evaluates an integer constant expression. Identifiers are looked up
+// with lookup if it isn't nil and are 0 otherwise like in #if.
+func evalConst(toks []token, lookup func(string) (int64, bool)) (int64, error) {
+	e := &evaluator{toks: toks, lookup: lookup}
+	v, err := e.ternary()
+	if err != nil {
+		return 0, err
+	}
+	if e.i < len(e.toks) {
+		return 0, fmt.Errorf("unexpected %q in constant expression", e.toks[e.i].text)
+	}
+	return v, nil
+}
+
+type evaluator struct {
+	toks   []token
+	i      int
+	lookup func(string) (int64, bool)
+}
+
+func (e *evaluator) peek() string {
+	if e.i < len(e.toks) {
+		return e.toks[e.i].text
+	}
+	return ""
+}
+
+func (e *evaluator) ternary() (int64, error) {
+	c, err := e.binary(0)
+	if err != nil || e.peek() != "?" {
+		return c, err
+	}
+	e.i++
+	a, err := e.ternary()
+	if err != nil {
+		return 0, err
+	}
+	if e.peek() != ":" {
+		return 0, errors.New("missing : in constant expression")
+	}
+	e.i++
+	b, err := e.ternary()
+	if err != nil {
+		return 0, err
+	}
+	if c != 0 {
+		return a, nil
+	}
+	return b, nil
+}
+
+// binaryOps are the binary operators by precedence from lowest to highest.
+var binaryOps = [][]string{
+	{"||"}, {"&&"}, {"|"}, {"^"}, {"&"}, {"==", "!="}, {"<", ">", "<=", ">="}, {"<<", ">>"}, {"+", "-"}, {"*", "/", "%"},
+}
+
+func (e *evaluator) binary(level int) (int64, error) {
+	if level == len(binaryOps) {
+		return e.unary()
+	}
+	x, err := e.binary(level + 1)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := e.peek()
+		found := false
+		for _, o := range binaryOps[level] {
+			found = found || o == op
+		}
+		if !found {
+			return x, nil
+		}
+		e.i++
+		y, err := e.binary(level + 1)
+		if err != nil {
+			return 0, err
+		}
+		b2i := func(b bool) int64 {
+			if b {
+				return 1
+			}
+			return 0
+		}
+		switch op {
+		case "||":
+			x = b2i(x != 0 || y != 0)
+		case "&&":
+			x = b2i(x != 0 && y != 0)
+		case "|":
+			x |= y
+		case "^":
+			x ^= y
+		case "&":
+			x &= y
+		case "==":
+			x = b2i(x == y)
+		case "!=":
+			x = b2i(x != y)
+		case "<":
+			x = b2i(x < y)
+		case ">":
+			x = b2i(x > y)
+		case "<=":
+			x = b2i(x <= y)
+		case ">=":
+			x = b2i(x >= y)
+		case "<<":
+			x <<= uint64(y)
+		case ">>":
+			x >>= uint64(y)
+		case "+":
+			x += y
+		case "-":
+			x -= y
+		case "*":
+			x *= y
+		case "/", "%":
+			if y == 0 {
+				return 0, errors.New("division by zero in constant expression")
+			}
+			if op == "/" {
+				x /= y
+			} else {
+				x %= y
+			}
+		}
+	}
+}
+
+func (e *evaluator) unary() (int64, error) {
+	if e.i >= len(e.toks) {
+		return 0, errors.New("unexpected end of constant expression")
+	}
+	t := e.toks[e.i]
+	e.i++
+	switch t.text {
+	case "!", "~", "-", "+":
+		x, err := e.unary()
+		switch t.text {
+		case "!":
+			if x == 0 {
+				return 1, err
+			}
+			return 0, err
+		case "~":
+			return ^x, err
+		case "-":
+			return -x, err
+		}
+		return x, err
+	case "(":
+		x, err := e.ternary()
+		if err != nil {
+			return 0, err
+		}
+		if e.peek() != ")" {
+			return 0, errors.New("missing ) in constant expression")
+		}
+		e.i++
+		return x, nil
+	}
+	switch t.kind {
+	case tokNumber:
+		return parseInt(t.text)
+	case tokChar:
+		s, err := strconv.Unquote(t.text)
+		if err != nil || len(s) == 0 {
+			return 0, fmt.Errorf("malformed character %s", t.text)
+		}
+		return int64(s[0]), nil
+	case tokIdent:
+		if e.lookup != nil {
+			if v, ok := e.lookup(t.text); ok {
+				return v, nil
+			}
+			return 0, fmt.Errorf("unknown constant %s", t.text)
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("unexpected %q in constant expression", t.text)
+}
+
+// parseInt parses a C integer literal.
+func parseInt(s string) (int64, error) {
+	lit := strings.TrimRight(s, "uUlL")
+	if strings.HasPrefix(lit, "0") && len(lit) > 1 && isDigit(lit[1]) {
+		lit = "0o" + lit[1:]
+	}
+	u, err := strconv.ParseUint(lit, 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed integer %s", s)
+	}
+	return int64(u), nil
+}
@@ -0,0 +1,18 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+#ifndef CONSTANTS_H
+#define CONSTANTS_H
+
+#define CT_VERSION 3
+#define CT_NAME "constants"
+#define CT_ALL (~0u)
+
+typedef struct ct_point {
+    double x, y;
+} ct_point;
+
+extern int ct_counter;
+extern const ct_point ct_origin;
+
+#endif
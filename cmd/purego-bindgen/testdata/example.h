@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+#ifndef EXAMPLE_H
+#define EXAMPLE_H
+
+#include <stddef.h>
+#include "types.h"
+
+#if defined(_WIN32)
+#define EX_API __declspec(dllimport)
+#elif defined(__GNUC__) && __GNUC__ >= 4
+#define EX_API __attribute__((visibility("default")))
+#else
+#define EX_API
+#endif
+
+#define EX_CONCAT(a, b) a##b
+#define EX_FLAG(n) (1u << (n))
+
+#define EX_MAX_NAME 32
+#define EX_FLAG_VISIBLE EX_FLAG(0)
+#define EX_FLAG_RESIZABLE EX_FLAG(1)
+#define EX_SCALE 1.5f
+#define EX_NAME "example" \
+    "-lib"
+#define EX_MASK (~0xffUL)
+#define EX_NOT_A_CONSTANT (int)sizeof(struct ex_window)
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+typedef enum ex_event_type {
+    EX_EVENT_NONE,
+    EX_EVENT_KEY = 0x10,
+    EX_EVENT_MOUSE,
+    EX_EVENT_LAST = EX_EVENT_MOUSE + 10,
+} ex_event_type;
+
+enum {
+    EX_ANON_A = -1,
+    EX_ANON_B,
+};
+
+/* a struct with C padding */
+typedef struct ex_event {
+    ex_event_type type;
+    int64_t timestamp;
+    char key;
+    double x, y;
+    unsigned short buttons[3];
+    struct {
+        uint8_t r, g, b;
+    } color;
+} ex_event;
+
+typedef union ex_value {
+    int32_t i;
+    double d;
+    void *p;
+} ex_value;
+
+typedef union ex_handle {
+    int32_t id;
+    void *ptr;
+} ex_handle;
+
+typedef struct ex_window ex_window;
+
+typedef void (*ex_callback)(const ex_event *event, void *userdata);
+
+struct ex_flags {
+    unsigned int visible : 1;
+};
+
+EX_API ex_window *ex_create_window(const char *title, int width, int height, uint32_t flags);
+EX_API void ex_destroy_window(ex_window *window);
+EX_API const char *ex_window_title(const ex_window *window);
+EX_API int ex_poll_event(ex_window *window, ex_event *event);
+EX_API void ex_set_callback(ex_window *window, ex_callback cb, void *userdata);
+EX_API size_t ex_format(char *buf, size_t len, const char *format, ...);
+EX_API long EX_CONCAT(ex_, get_ticks)(void);
+EX_API ex_id ex_window_id(ex_window *window);
+EX_API ex_value ex_get_value(int index);
+EX_API ex_handle ex_get_handle(ex_window *window);
+EX_API void ex_fill(float values[4], unsigned long long n, _Bool clear);
+EX_API long double ex_precise(void);
+EX_API void ex_set_flags(struct ex_flags flags);
+
+static inline int ex_version(void) {
+    return EX_VERSION;
+}
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif
@@ -0,0 +1,15 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+#ifndef TYPES_H
+#define TYPES_H
+
+#include <stdint.h>
+
+#define EX_VERSION_MAJOR 1
+#define EX_VERSION_MINOR 4
+#define EX_VERSION ((EX_VERSION_MAJOR << 16) | EX_VERSION_MINOR)
+
+typedef uint32_t ex_id;
+
+#endif
@@ -0,0 +1,547 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const puregoPath = "github.com/ebitengine/purego"
+
+type config struct {
+	files []string
+	out   string // the output file which is left out when type checking the package
+	tags  string
+}
+
+// library is declared by a purego:library directive.
+type library struct {
+	name, file string
+}
+
+// binding is a function declaration annotated with purego:bind.
+type binding struct {
+	lib, sym string
+	decl     *ast.FuncDecl
+	fn       *types.Func
+	proc     string // the name of the variable holding the LazyProc
+}
+
+func generate(c config) ([]byte, error) {
+	fset := token.NewFileSet()
+	var decls []*ast.File
+	var inputs []string
+	for _, name := range c.files {
+		path, err := filepath.Abs(name)
+		if err != nil {
+			return nil, err
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		if len(decls) > 0 && f.Name.Name != decls[0].Name.Name {
+			return nil, fmt.Errorf("%s: package %s is not package %s", name, f.Name.Name, decls[0].Name.Name)
+		}
+		decls = append(decls, f)
+		inputs = append(inputs, path)
+	}
+	libs, bindings, err := directives(fset, decls)
+	if err != nil {
+		return nil, err
+	}
+
+	// The rest of the package declares the types the declarations use.
+	dir := filepath.Dir(inputs[0])
+	skip := append([]string{}, inputs...)
+	if c.out != "" {
+		out, err := filepath.Abs(c.out)
+		if err != nil {
+			return nil, err
+		}
+		skip = append(skip, out)
+	}
+	others, err := packageFiles(fset, dir, skip)
+	if err != nil {
+		return nil, err
+	}
+	info := &types.Info{Defs: map[*ast.Ident]types.Object{}}
+	var typeErr error
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error: func(err error) {
+			if typeErr == nil {
+				typeErr = err
+			}
+		},
+	}
+	pkg, _ := conf.Check(decls[0].Name.Name, fset, append(others, decls...), info)
+	for _, b := range bindings {
+		b.fn = info.Defs[b.decl.Name].(*types.Func)
+		if hasInvalidType(b.fn.Type().(*types.Signature)) {
+			return nil, typeErr
+		}
+	}
+
+	var names []string
+	for _, f := range c.files {
+		names = append(names, filepath.Base(f))
+	}
+	g := &generator{fset: fset, pkg: pkg, imports: map[string]string{}}
+	return g.file(c.tags, strings.Join(names, ", "), libs, bindings)
+}
+
+// directives returns the libraries and bindings the files declare.
+func directives(fset *token.FileSet, files []*ast.File) ([]library, []*binding, error) {
+	var libs []library
+	var bindings []*binding
+	for _, f := range files {
+		for _, group := range f.Comments {
+			for _, c := range group.List {
+				args, ok := directive(c.Text, "//purego:library")
+				if !ok {
+					continue
+				}
+				if len(args) != 2 {
+					return nil, nil, fmt.Errorf("%s: usage: //purego:library name file", fset.Position(c.Pos()))
+				}
+				libs = append(libs, library{name: args[0], file: args[1]})
+			}
+		}
+		for _, d := range f.Decls {
+			decl, ok := d.(*ast.FuncDecl)
+			if !ok || decl.Doc == nil {
+				continue
+			}
+			for _, c := range decl.Doc.List {
+				args, ok := directive(c.Text, "//purego:bind")
+				if !ok {
+					continue
+				}
+				pos := fset.Position(c.Pos())
+				switch {
+				case len(args) != 2:
+					return nil, nil, fmt.Errorf("%s: usage: //purego:bind library symbol", pos)
+				case decl.Recv != nil:
+					return nil, nil, fmt.Errorf("%s: %s is a method", pos, decl.Name.Name)
+				case decl.Body != nil:
+					return nil, nil, fmt.Errorf("%s: %s must not have a body", pos, decl.Name.Name)
+				}
+				bindings = append(bindings, &binding{lib: args[0], sym: args[1], decl: decl})
+			}
+		}
+	}
+	return libs, bindings, nil
+}
+
+// directive returns the arguments of the comment text if it is the directive name.
+func directive(text, name string) ([]string, bool) {
+	rest := strings.TrimPrefix(text, name)
+	if rest == text || rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return nil, false
+	}
+	return strings.Fields(rest), true
+}
+
+// packageFiles parses the files of the package in dir that are part of the build except skip.
+func packageFiles(fset *token.FileSet, dir string, skip []string) ([]*ast.File, error) {
+	p, err := build.ImportDir(dir, 0)
+	if err != nil {
+		var noGo *build.NoGoError
+		if errors.As(err, &noGo) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var files []*ast.File
+	for _, name := range p.GoFiles {
+		path := filepath.Join(dir, name)
+		if contains(skip, path) {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasInvalidType(sig *types.Signature) bool {
+	for _, tuple := range []*types.Tuple{sig.Params(), sig.Results()} {
+		for i := 0; i < tuple.Len(); i++ {
+			if tuple.At(i).Type() == types.Typ[types.Invalid] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type generator struct {
+	fset    *token.FileSet
+	pkg     *types.Package
+	imports map[string]string // the path and name of every package the output uses
+}
+
+func (g *generator) file(tags, source string, libs []library, bindings []*binding) ([]byte, error) {
+	var body bytes.Buffer
+	g.imports[puregoPath] = "purego"
+	switch len(libs) {
+	case 0:
+	case 1:
+		fmt.Fprintf(&body, "var %s = purego.NewLazyLibrary(%q)\n\n", libs[0].name, libs[0].file)
+	default:
+		body.WriteString("var (\n")
+		for _, l := range libs {
+			fmt.Fprintf(&body, "%s = purego.NewLazyLibrary(%q)\n", l.name, l.file)
+		}
+		body.WriteString(")\n\n")
+	}
+	if len(bindings) > 0 {
+		used := map[string]bool{}
+		body.WriteString("var (\n")
+		for _, b := range bindings {
+			b.proc = "proc" + strings.ToUpper(b.fn.Name()[:1]) + b.fn.Name()[1:]
+			for used[b.proc] {
+				b.proc += "_"
+			}
+			used[b.proc] = true
+			fmt.Fprintf(&body, "%s = %s.NewProc(%q)\n", b.proc, b.lib, b.sym)
+		}
+		body.WriteString(")\n")
+	}
+	for _, b := range bindings {
+		body.WriteString("\n")
+		if err := g.wrapper(&body, b); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", g.fset.Position(b.decl.Pos()), b.fn.Name(), err)
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by purego-gen from %s. DO NOT EDIT.\n\n", source)
+	if tags != "" {
+		fmt.Fprintf(&out, "//go:build %s\n\n", tags)
+	}
+	fmt.Fprintf(&out, "package %s\n\n", g.pkg.Name())
+	var std, other []string
+	for path := range g.imports {
+		if strings.Contains(strings.SplitN(path, "/", 2)[0], ".") {
+			other = append(other, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+	out.WriteString("import (\n")
+	for _, path := range std {
+		g.importSpec(&out, path)
+	}
+	if len(std) > 0 {
+		out.WriteString("\n")
+	}
+	for _, path := range other {
+		g.importSpec(&out, path)
+	}
+	out.WriteString(")\n\n")
+	out.Write(body.Bytes())
+	return format.Source(out.Bytes())
+}
+
+func (g *generator) importSpec(out *bytes.Buffer, path string) {
+	if name := g.imports[path]; name != filepath.Base(path) {
+		fmt.Fprintf(out, "%s %q\n", name, path)
+		return
+	}
+	fmt.Fprintf(out, "%q\n", path)
+}
+
+// typeString returns t as it is written in the output.
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == g.pkg {
+			return ""
+		}
+		g.imports[p.Path()] = p.Name()
+		return p.Name()
+	})
+}
+
+func isNamed(t types.Type) bool {
+	return t != t.Underlying()
+}
+
+// convert returns the expression x converted to t if t is a named type.
+func (g *generator) convert(t types.Type, x string) string {
+	if isNamed(t) {
+		return g.typeString(t) + "(" + x + ")"
+	}
+	return x
+}
+
+func isVariadicMarker(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == puregoPath && named.Obj().Name() == "Variadic"
+}
+
+func isErrno(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "syscall" && named.Obj().Name() == "Errno"
+}
+
+func isError(t types.Type) bool {
+	return types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+func (g *generator) wrapper(w *bytes.Buffer, b *binding) error {
+	sig := b.fn.Type().(*types.Signature)
+	if sig.Variadic() {
+		return errors.New("Go variadic parameters are not supported")
+	}
+
+	// the names of the parameters and the local variables must not clash
+	used := map[string]bool{}
+	names := make([]string, sig.Params().Len())
+	for i := range names {
+		name := sig.Params().At(i).Name()
+		if name == "" || name == "_" && !isVariadicMarker(sig.Params().At(i).Type()) {
+			name = fmt.Sprintf("a%d", i)
+		}
+		names[i] = name
+		used[name] = true
+	}
+	local := func(name string) string {
+		for used[name] {
+			name += "_"
+		}
+		used[name] = true
+		return name
+	}
+	f, r := local("f"), local("r")
+
+	var result, errno types.Type
+	switch results := sig.Results(); results.Len() {
+	case 0:
+	case 1:
+		if t := results.At(0).Type(); isError(t) || isErrno(t) {
+			errno = t
+		} else {
+			result = t
+		}
+	case 2:
+		result, errno = results.At(0).Type(), results.At(1).Type()
+		if !isError(errno) && !isErrno(errno) {
+			return errors.New("the second result must be error or syscall.Errno")
+		}
+	default:
+		return errors.New("too many results")
+	}
+
+	if b.decl.Doc != nil {
+		for _, line := range strings.Split(strings.TrimSuffix(b.decl.Doc.Text(), "\n"), "\n") {
+			if line == "" {
+				w.WriteString("//\n")
+			} else {
+				fmt.Fprintf(w, "// %s\n", line)
+			}
+		}
+	}
+	fmt.Fprintf(w, "func %s(", b.fn.Name())
+	for i, name := range names {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		fmt.Fprintf(w, "%s %s", name, g.typeString(sig.Params().At(i).Type()))
+	}
+	w.WriteString(") ")
+	if sig.Results().Len() > 0 {
+		w.WriteString("(")
+		for i := 0; i < sig.Results().Len(); i++ {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			w.WriteString(g.typeString(sig.Results().At(i).Type()))
+		}
+		w.WriteString(") ")
+	}
+	w.WriteString("{\n")
+	fmt.Fprintf(w, "%s := purego.NewFrame(%s.Addr())\n", f, b.proc)
+	fmt.Fprintf(w, "defer %s.Free()\n", f)
+
+	var ret string
+	if result != nil {
+		switch u := result.Underlying().(type) {
+		case *types.Struct:
+			g.structResult(w, f, r, result)
+			ret = r
+		case *types.Basic:
+			switch k := u.Kind(); {
+			case k == types.Bool:
+				ret = g.convert(result, "byte("+f+".IntResult()) != 0")
+			case k == types.Float32:
+				ret = g.convert(result, f+".Float32Result()")
+			case k == types.Float64:
+				ret = g.convert(result, f+".Float64Result()")
+			case k == types.String:
+				ret = g.convert(result, f+".StringResult()")
+			case k == types.UnsafePointer:
+				g.imports["unsafe"] = "unsafe"
+				ret = g.convert(result, f+".PointerResult()")
+			case k == types.Complex64 || k == types.Complex128:
+				g.structResult(w, f, r, result)
+				ret = r
+			case k == types.Uintptr:
+				ret = g.convert(result, f+".IntResult()")
+			case u.Info()&types.IsInteger != 0:
+				ret = g.typeString(result) + "(" + f + ".IntResult())"
+			default:
+				return fmt.Errorf("unsupported result type %s", g.typeString(result))
+			}
+		case *types.Pointer:
+			ret = "(" + g.typeString(result) + ")(" + f + ".PointerResult())"
+		default:
+			return fmt.Errorf("unsupported result type %s", g.typeString(result))
+		}
+	}
+	if errno != nil {
+		fmt.Fprintf(w, "%s.CaptureErrno()\n", f)
+	}
+
+	var keepAlive []string
+	variadic := false
+	for i, name := range names {
+		t := sig.Params().At(i).Type()
+		if isVariadicMarker(t) {
+			if variadic {
+				return errors.New("purego.Variadic can only be used once")
+			}
+			variadic = true
+			fmt.Fprintf(w, "%s.Variadic()\n", f)
+			continue
+		}
+		switch u := t.Underlying().(type) {
+		case *types.Basic:
+			switch k := u.Kind(); {
+			case k == types.Bool:
+				fmt.Fprintf(w, "%s.Bool(%s)\n", f, g.convertTo(t, "bool", name))
+			case k == types.Float32 && variadic:
+				fmt.Fprintf(w, "%s.Float64(float64(%s))\n", f, name)
+			case k == types.Float32:
+				fmt.Fprintf(w, "%s.Float32(%s)\n", f, g.convertTo(t, "float32", name))
+			case k == types.Float64:
+				fmt.Fprintf(w, "%s.Float64(%s)\n", f, g.convertTo(t, "float64", name))
+			case k == types.String:
+				fmt.Fprintf(w, "%s.String(%s)\n", f, g.convertTo(t, "string", name))
+			case k == types.UnsafePointer:
+				g.imports["unsafe"] = "unsafe"
+				fmt.Fprintf(w, "%s.Pointer(%s)\n", f, g.convertTo(t, "unsafe.Pointer", name))
+				keepAlive = append(keepAlive, name)
+			case k == types.Complex64 || k == types.Complex128:
+				if variadic {
+					return fmt.Errorf("%s arguments after Variadic are not supported", g.typeString(t))
+				}
+				g.structArg(w, f, name, t)
+			case u.Info()&types.IsInteger != 0:
+				g.imports["unsafe"] = "unsafe"
+				fmt.Fprintf(w, "%s.Int(uintptr(%s), unsafe.Sizeof(%s))\n", f, name, name)
+			default:
+				return fmt.Errorf("unsupported parameter type %s", g.typeString(t))
+			}
+		case *types.Pointer:
+			g.imports["unsafe"] = "unsafe"
+			fmt.Fprintf(w, "%s.Pointer(unsafe.Pointer(%s))\n", f, name)
+			keepAlive = append(keepAlive, name)
+		case *types.Slice:
+			g.imports["unsafe"] = "unsafe"
+			fmt.Fprintf(w, "%s.Pointer(*(*unsafe.Pointer)(unsafe.Pointer(&%s)))\n", f, name)
+			keepAlive = append(keepAlive, name)
+		case *types.Signature:
+			g.imports["unsafe"] = "unsafe"
+			fmt.Fprintf(w, "%s.Int(purego.NewCallback(%s), unsafe.Sizeof(uintptr(0)))\n", f, name)
+		case *types.Struct:
+			if variadic {
+				return fmt.Errorf("%s arguments after Variadic are not supported", g.typeString(t))
+			}
+			g.structArg(w, f, name, t)
+		default:
+			return fmt.Errorf("unsupported parameter type %s", g.typeString(t))
+		}
+	}
+
+	fmt.Fprintf(w, "%s.Call()\n", f)
+	if len(keepAlive) > 0 {
+		g.imports["runtime"] = "runtime"
+		for _, name := range keepAlive {
+			fmt.Fprintf(w, "runtime.KeepAlive(%s)\n", name)
+		}
+	}
+	switch {
+	case errno != nil && isErrno(errno):
+		if ret != "" {
+			ret += ", "
+		}
+		fmt.Fprintf(w, "return %s%s.Errno()\n", ret, f)
+	case errno != nil:
+		if ret != "" {
+			if ret != r {
+				fmt.Fprintf(w, "%s := %s\n", r, ret)
+			}
+			ret = r + ", "
+		}
+		fmt.Fprintf(w, "if errno := %s.Errno(); errno != 0 {\nreturn %serrno\n}\n", f, ret)
+		fmt.Fprintf(w, "return %snil\n", ret)
+	case ret != "":
+		fmt.Fprintf(w, "return %s\n", ret)
+	}
+	w.WriteString("}\n")
+	return nil
+}
+
+// convertTo returns the expression x converted to the basic type named basic if t is named.
+func (g *generator) convertTo(t types.Type, basic, x string) string {
+	if isNamed(t) {
+		return basic + "(" + x + ")"
+	}
+	return x
+}
+
+// typeOf returns an expression for the reflect.Type of t that doesn't allocate.
+func (g *generator) typeOf(t types.Type) string {
+	g.imports["reflect"] = "reflect"
+	return "reflect.TypeOf((*" + g.typeString(t) + ")(nil)).Elem()"
+}
+
+func (g *generator) structArg(w *bytes.Buffer, f, name string, t types.Type) {
+	g.imports["unsafe"] = "unsafe"
+	fmt.Fprintf(w, "%s.Struct(unsafe.Pointer(&%s), %s)\n", f, name, g.typeOf(t))
+}
+
+func (g *generator) structResult(w *bytes.Buffer, f, r string, t types.Type) {
+	g.imports["unsafe"] = "unsafe"
+	fmt.Fprintf(w, "var %s %s\n", r, g.typeString(t))
+	fmt.Fprintf(w, "%s.StructResult(unsafe.Pointer(&%s), %s)\n", f, r, g.typeOf(t))
+}
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+// Purego-gen generates typed Go wrappers for C functions from annotated Go declarations.
+// Unlike purego.RegisterFunc the wrappers need no reflect.MakeFunc. They fill the arguments
+// in a purego.Frame which places them like RegisterFunc does and call the C function directly.
+// Only struct and complex arguments and results still use reflection to place their fields.
+// Since purego.Frame is experimental, regenerate the wrappers after upgrading purego.
+//
+// Usage:
+//
+//	purego-gen [flags] file.go...
+//
+// The files declare a function without a body for every C function, annotated with the
+// library and the symbol:
+//
+//	//go:build ignore
+//
+//	package libc
+//
+//	//purego:library libc libc.so.6
+//
+//	//purego:bind libc puts
+//	func Puts(s string) int32
+//
+// Since the declarations have no body the files must be excluded from the build, for example
+// with the ignore build constraint. The go:generate directive goes in another file:
+//
+//	//go:generate go run github.com/ebitengine/purego/cmd/purego-gen -o zlibc.go libc.go
+//
+// A purego:library directive declares a variable holding a purego.LazyLibrary for the file.
+// Without it the package must declare a variable of that name itself. Its NewProc method is
+// called with the symbol and the result must have an Addr() uintptr method. This works with
+// purego.LazyLibrary as well as syscall.LazyDLL on Windows.
+//
+// The parameters and results may be of the types RegisterFunc supports with a few exceptions.
+// Go variadic parameters and func results are not supported. Arguments after a purego.Variadic
+// parameter get the C default argument promotions. Structs and complex numbers are passed
+// through their reflect.Type which purego classifies the same way for RegisterFunc.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	out := flag.String("o", "", "write the output to `file` instead of stdout")
+	tags := flag.String("tags", "", "add the build constraint `expr` to the output")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: purego-gen [flags] file.go...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	src, err := generate(config{files: flag.Args(), out: *out, tags: *tags})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "purego-gen: %v\n", err)
+		os.Exit(1)
+	}
+	if *out == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "purego-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestGolden(t *testing.T) {
+	src, err := generate(config{files: []string{filepath.Join("testdata", "libc", "libc.go")}, tags: "linux"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "libc", "zlibc.go.golden")
+	if *update {
+		if err := os.WriteFile(golden, src, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, want) {
+		t.Errorf("purego-gen output differs from %s; run go test -update to update it:\n%s", golden, src)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		err  string
+	}{
+		{"body", "//purego:bind libc abs\nfunc Abs(x int32) int32 { return x }", "Abs must not have a body"},
+		{"usage", "//purego:bind libc\nfunc Abs(x int32) int32", "usage: //purego:bind library symbol"},
+		{"variadic", "//purego:bind libc printf\nfunc Printf(format string, args ...any) int32", "Go variadic parameters are not supported"},
+		{"map", "//purego:bind libc f\nfunc F(m map[int]int)", "unsupported parameter type map[int]int"},
+		{"func result", "//purego:bind libc f\nfunc F() func()", "unsupported result type func()"},
+		{"errno", "//purego:bind libc f\nfunc F() (int32, int32)", "the second result must be error or syscall.Errno"},
+		{"type", "//purego:bind libc f\nfunc F(x Undefined)", "undefined: Undefined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "decls.go")
+			if err := os.WriteFile(file, []byte("package decls\n\n"+tt.src+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := generate(config{files: []string{file}})
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("generate returned %v wanted an error containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// TestRun builds and runs a program that calls libc through the generated wrappers.
+func TestRun(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the test library is libc.so.6")
+	}
+	if testing.Short() {
+		t.Skip("builds a program")
+	}
+	src, err := generate(config{files: []string{filepath.Join("testdata", "libc", "libc.go")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	types, err := os.ReadFile(filepath.Join("testdata", "libc", "types.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	goMod := "module example.com/gentest\n\ngo 1.18\n\nrequire github.com/ebitengine/purego v0.0.0\n\nreplace github.com/ebitengine/purego => " + root + "\n"
+	files := map[string]string{
+		"go.mod":                          goMod,
+		filepath.Join("libc", "types.go"): string(types),
+		filepath.Join("libc", "zlibc.go"): string(src),
+		"main.go": `package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/ebitengine/purego"
+
+	"example.com/gentest/libc"
+)
+
+func main() {
+	var end *byte
+	buf := make([]byte, 64)
+	n := libc.Snprintf(buf, uintptr(len(buf)), "%d %.1f %s", purego.Variadic{}, 42, 2.5, "go")
+	_, err := libc.Chdir("/nonexistent")
+	fmt.Println(libc.Strlen("purego"), libc.Abs(-7), string(rune(libc.Toupper('q'))), libc.Div(17, 5),
+		libc.Strtof("1.25x", &end), *end == 'x', string(buf[:bytes.IndexByte(buf, 0)]), n, errors.Is(err, fs.ErrNotExist))
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go run failed: %v\n%s", err, out)
+	}
+	const want = "6 7 Q {3 2} 1.25 true 42 2.5 go 9 true\n"
+	if string(out) != want {
+		t.Errorf("the program printed %q wanted %q", out, want)
+	}
+}
@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build ignore
+
+package libc
+
+import "github.com/ebitengine/purego"
+
+//purego:library libc libc.so.6
+
+// Strlen returns the length of s.
+//
+//purego:bind libc strlen
+func Strlen(s string) uintptr
+
+//purego:bind libc abs
+func Abs(x int32) int32
+
+//purego:bind libc toupper
+func Toupper(c Char) Char
+
+//purego:bind libc div
+func Div(num, denom int32) DivT
+
+//purego:bind libc strtof
+func Strtof(s string, end **byte) float32
+
+//purego:bind libc snprintf
+func Snprintf(buf []byte, size uintptr, format string, _ purego.Variadic, i int32, f float32, s string) int32
+
+//purego:bind libc chdir
+func Chdir(path string) (int32, error)
+
+//purego:bind libc getenv
+func Getenv(name string) *byte
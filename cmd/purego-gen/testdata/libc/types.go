@@ -0,0 +1,12 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+package libc
+
+// Char is a character as C's ctype functions take it.
+type Char int32
+
+// DivT is div_t.
+type DivT struct {
+	Quot, Rem int32
+}
@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build darwin || (linux && (amd64 || arm64 || loong64))
+
+package purego_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ebitengine/purego"
+)
+
+func TestRegisterFunc_complex(t *testing.T) {
+	libFileName := filepath.Join(t.TempDir(), "complextest.so")
+	t.Logf("Build %v", libFileName)
+
+	if err := buildSharedLib("CC", libFileName, filepath.Join("testdata", "complextest", "complex_test.c")); err != nil {
+		t.Fatal(err)
+	}
+
+	lib, err := purego.Dlopen(libFileName, purego.RTLD_NOW|purego.RTLD_GLOBAL)
+	if err != nil {
+		t.Fatalf("Dlopen(%q) failed: %v", libFileName, err)
+	}
+
+	{
+		var ComplexFloatMul func(a, b complex64) complex64
+		purego.RegisterLibFunc(&ComplexFloatMul, lib, "ComplexFloatMul")
+		if ret, want := ComplexFloatMul(1+2i, 3-4i), complex64(11+2i); ret != want {
+			t.Errorf("ComplexFloatMul returned %v wanted %v", ret, want)
+		}
+	}
+	{
+		type Complex complex128
+		var ComplexDoubleMul func(a, b Complex) Complex
+		purego.RegisterLibFunc(&ComplexDoubleMul, lib, "ComplexDoubleMul")
+		if ret, want := ComplexDoubleMul(1.5+2i, -2+0.5i), Complex(-4-3.25i); ret != want {
+			t.Errorf("ComplexDoubleMul returned %v wanted %v", ret, want)
+		}
+	}
+	{
+		var ComplexMixed func(a int32, b complex64, c float64, d complex128) float64
+		purego.RegisterLibFunc(&ComplexMixed, lib, "ComplexMixed")
+		if ret, want := ComplexMixed(1, 2+3i, 4, 5+6i), 21.0; ret != want {
+			t.Errorf("ComplexMixed returned %v wanted %v", ret, want)
+		}
+	}
+	{
+		var ComplexAfterDoubles func(a, b, c, e, f, g, h float64, d complex128) complex128
+		purego.RegisterLibFunc(&ComplexAfterDoubles, lib, "ComplexAfterDoubles")
+		if ret, want := ComplexAfterDoubles(1, 2, 3, 4, 5, 6, 7, 0.5+8i), 28.5+8i; ret != want {
+			t.Errorf("ComplexAfterDoubles returned %v wanted %v", ret, want)
+		}
+	}
+	{
+		var ComplexAfterFloats func(a, b, c, e, f, g, h float32, d complex64) complex64
+		purego.RegisterLibFunc(&ComplexAfterFloats, lib, "ComplexAfterFloats")
+		if ret, want := ComplexAfterFloats(1, 2, 3, 4, 5, 6, 7, 0.5+8i), complex64(28.5+8i); ret != want {
+			t.Errorf("ComplexAfterFloats returned %v wanted %v", ret, want)
+		}
+	}
+	{
+		var a complex64
+		var b complex128
+		cb := purego.NewCallback(func(x complex64, y complex128) int32 {
+			a, b = x, y
+			return 1
+		})
+		var CallComplexCallback func(cb uintptr, a complex64, b complex128) int32
+		purego.RegisterLibFunc(&CallComplexCallback, lib, "CallComplexCallback")
+		if ret := CallComplexCallback(cb, 1+2i, 3+4i); ret != 1 || a != 1+2i || b != 3+4i {
+			t.Errorf("CallComplexCallback got (%d, %v, %v) wanted (1, %v, %v)", ret, a, b, 1+2i, 3+4i)
+		}
+	}
+	{
+		var sum float64
+		var d complex128
+		var f complex64
+		cb := purego.NewCallback(func(a1, a2, a3, a4, a5, a6, a7 float64, x complex128, y complex64) int32 {
+			sum = a1 + a2 + a3 + a4 + a5 + a6 + a7
+			d, f = x, y
+			return 1
+		})
+		var CallComplexCallbackAfterDoubles func(cb uintptr, d complex128, f complex64) int32
+		purego.RegisterLibFunc(&CallComplexCallbackAfterDoubles, lib, "CallComplexCallbackAfterDoubles")
+		if ret := CallComplexCallbackAfterDoubles(cb, 5-6i, 7+8i); ret != 1 || sum != 28 || d != 5-6i || f != 7+8i {
+			t.Errorf("CallComplexCallbackAfterDoubles got (%d, %v, %v, %v) wanted (1, 28, %v, %v)", ret, sum, d, f, 5-6i, 7+8i)
+		}
+	}
+}
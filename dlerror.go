@@ -5,13 +5,69 @@
 
 package purego
 
+import (
+	"errors"
+	"strings"
+)
+
+// The kinds of errors Dlopen and Dlsym report. Use errors.Is to check for them.
+var (
+	// ErrLibraryNotFound means the library or one of its dependencies doesn't exist.
+	ErrLibraryNotFound = errors.New("purego: library not found")
+	// ErrSymbolNotFound means Dlsym didn't find the symbol.
+	ErrSymbolNotFound = errors.New("purego: symbol not found")
+	// ErrWrongELFClass means the library is built for another architecture or pointer size.
+	ErrWrongELFClass = errors.New("purego: library is built for another architecture")
+	// ErrUndefinedSymbol means the library refers to a symbol that no loaded library defines.
+	ErrUndefinedSymbol = errors.New("purego: library refers to an undefined symbol")
+)
+
 // Dlerror represents an error value returned from Dlopen, Dlsym, or Dlclose.
+// Its message is the one of dlerror and it wraps one of the errors above
+// when the kind of failure is known.
 //
 // This type is not available on Windows as there is no counterpart to it on Windows.
 type Dlerror struct {
-	s string
+	s   string
+	err error
 }
 
 func (e Dlerror) Error() string {
 	return e.s
 }
+
+func (e Dlerror) Unwrap() error {
+	return e.err
+}
+
+// dlopenError classifies the message of dlerror after Dlopen of path failed. The messages
+// differ between glibc, musl, the BSDs and macOS.
+func dlopenError(path, msg string) Dlerror {
+	var err error
+	switch {
+	case msg == "":
+		// RTLD_NOLOAD fails without a message if the library isn't loaded
+		msg, err = path+": not loaded", ErrLibraryNotFound
+	case containsAny(msg, "wrong ELF class", "incompatible architecture"):
+		err = ErrWrongELFClass
+	case containsAny(msg, "undefined symbol", "Undefined symbol", "symbol not found", "Symbol not found"):
+		err = ErrUndefinedSymbol
+	case containsAny(msg, "No such file", "no such file", "image not found", "not found"):
+		err = ErrLibraryNotFound
+	}
+	return Dlerror{s: msg, err: err}
+}
+
+// dlsymError returns the error for the message of dlerror after Dlsym failed.
+func dlsymError(msg string) Dlerror {
+	return Dlerror{s: msg, err: ErrSymbolNotFound}
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
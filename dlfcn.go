@@ -6,6 +6,7 @@
 package purego
 
 import (
+	"runtime"
 	"unsafe"
 )
 
@@ -38,9 +39,12 @@ func init() {
 // Use [golang.org/x/sys/windows.LoadLibrary], [golang.org/x/sys/windows.LoadLibraryEx],
 // [golang.org/x/sys/windows.NewLazyDLL], or [golang.org/x/sys/windows.NewLazySystemDLL] for Windows instead.
 func Dlopen(path string, mode int) (uintptr, error) {
+	// dlerror is thread-local so it must be read on the thread that failed
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 	u := fnDlopen(path, mode)
 	if u == 0 {
-		return 0, Dlerror{fnDlerror()}
+		return 0, dlopenError(path, fnDlerror())
 	}
 	return u, nil
 }
@@ -53,9 +57,11 @@ func Dlopen(path string, mode int) (uintptr, error) {
 // This function is not available on Windows.
 // Use [golang.org/x/sys/windows.GetProcAddress] for Windows instead.
 func Dlsym(handle uintptr, name string) (uintptr, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 	u := fnDlsym(handle, name)
 	if u == 0 {
-		return 0, Dlerror{fnDlerror()}
+		return 0, dlsymError(fnDlerror())
 	}
 	return u, nil
 }
@@ -67,8 +73,16 @@ func Dlsym(handle uintptr, name string) (uintptr, error) {
 // This function is not available on Windows.
 // Use [golang.org/x/sys/windows.FreeLibrary] for Windows instead.
 func Dlclose(handle uintptr) error {
+	err := closeHandle(handle)
+	releaseMemfds()
+	return err
+}
+
+func closeHandle(handle uintptr) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 	if fnDlclose(handle) {
-		return Dlerror{fnDlerror()}
+		return Dlerror{s: fnDlerror()}
 	}
 	return nil
 }
@@ -18,15 +18,32 @@ const (
 )
 
 func Dlopen(path string, mode int) (uintptr, error) {
-	return cgo.Dlopen(path, mode)
+	handle, err := cgo.Dlopen(path, mode)
+	if err != nil {
+		return 0, dlopenError(path, err.Error())
+	}
+	return handle, nil
 }
 
 func Dlsym(handle uintptr, name string) (uintptr, error) {
-	return cgo.Dlsym(handle, name)
+	sym, err := cgo.Dlsym(handle, name)
+	if err != nil {
+		return 0, dlsymError(err.Error())
+	}
+	return sym, nil
 }
 
 func Dlclose(handle uintptr) error {
-	return cgo.Dlclose(handle)
+	err := closeHandle(handle)
+	releaseMemfds()
+	return err
+}
+
+func closeHandle(handle uintptr) error {
+	if err := cgo.Dlclose(handle); err != nil {
+		return Dlerror{s: err.Error()}
+	}
+	return nil
 }
 
 func loadSymbol(handle uintptr, name string) (uintptr, error) {
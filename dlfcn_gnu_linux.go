@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build !android && !faketime
+
+package purego
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"github.com/ebitengine/purego/internal/strings"
+)
+
+// Requests for Dlinfo.
+// Source: https://codebrowser.dev/glibc/glibc/dlfcn/dlfcn.h.html
+const (
+	RTLD_DI_LMID        = 1  // Get the namespace of the handle as a Lmid_t.
+	RTLD_DI_LINKMAP     = 2  // Get the *LinkMap of the handle.
+	RTLD_DI_SERINFO     = 4  // Get the search paths of the handle as a Dl_serinfo.
+	RTLD_DI_SERINFOSIZE = 5  // Get the size of the buffer RTLD_DI_SERINFO needs.
+	RTLD_DI_ORIGIN      = 6  // Copy the directory of the handle into a buffer of PATH_MAX bytes.
+	RTLD_DI_TLS_MODID   = 9  // Get the TLS module ID of the handle as a size_t.
+	RTLD_DI_TLS_DATA    = 10 // Get a pointer to the TLS block of the calling thread for the handle.
+)
+
+var (
+	fnDladdr  func(addr uintptr, info *dlInfo) int32
+	fnDlinfo  func(handle uintptr, request int32, arg unsafe.Pointer) int32
+	fnDlvsym  func(handle uintptr, name, version string) uintptr
+	fnDlmopen func(lmid int, path string, mode int) uintptr
+)
+
+func init() {
+	RegisterFunc(&fnDladdr, dladdrABI0)
+	RegisterFunc(&fnDlinfo, dlinfoABI0)
+	RegisterFunc(&fnDlvsym, dlvsymABI0)
+	RegisterFunc(&fnDlmopen, dlmopenABI0)
+}
+
+// dlInfo is Dl_info.
+type dlInfo struct {
+	fname uintptr
+	fbase uintptr
+	sname uintptr
+	saddr uintptr
+}
+
+// SymbolInfo describes the shared object and symbol an address belongs to.
+type SymbolInfo struct {
+	Path       string  // the path of the shared object
+	Base       uintptr // the address the shared object is loaded at
+	Symbol     string  // the name of the nearest symbol below the address or "" if there is none
+	SymbolAddr uintptr // the address of Symbol
+}
+
+// LinkMap is the public part of the link_map the dynamic linker keeps for every loaded
+// shared object. Dlinfo with RTLD_DI_LINKMAP returns it.
+type LinkMap struct {
+	Addr uintptr  // the difference between the addresses in the shared object and in memory
+	Name *byte    // the absolute path of the shared object as a C string
+	Ld   uintptr  // the address of the dynamic section
+	Next *LinkMap // the next loaded shared object
+	Prev *LinkMap // the previous loaded shared object
+}
+
+// Path returns the path of the shared object. It is empty for the main program.
+func (m *LinkMap) Path() string {
+	return strings.GoString(uintptr(unsafe.Pointer(m.Name)))
+}
+
+// Dladdr finds the shared object containing addr and the nearest symbol below it.
+// This is useful to symbolize addresses of C code in crash reports.
+func Dladdr(addr uintptr) (SymbolInfo, error) {
+	var info dlInfo
+	if fnDladdr(addr, &info) == 0 {
+		return SymbolInfo{}, fmt.Errorf("purego: address %#x is not in a shared object", addr)
+	}
+	return SymbolInfo{
+		Path:       strings.GoString(info.fname),
+		Base:       info.fbase,
+		Symbol:     strings.GoString(info.sname),
+		SymbolAddr: info.saddr,
+	}, nil
+}
+
+// Dlinfo gets the information about the handle that request asks for and stores it at arg.
+// The type arg points to depends on the request.
+func Dlinfo(handle uintptr, request int, arg unsafe.Pointer) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if fnDlinfo(handle, int32(request), arg) != 0 {
+		return Dlerror{s: fnDlerror()}
+	}
+	return nil
+}
+
+// DlinfoLinkMap returns the LinkMap of the handle.
+func DlinfoLinkMap(handle uintptr) (*LinkMap, error) {
+	var m *LinkMap
+	if err := Dlinfo(handle, RTLD_DI_LINKMAP, unsafe.Pointer(&m)); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Dlvsym is like Dlsym but returns the given version of the symbol name,
+// for example version "GLIBC_2.2.5" of "memcpy".
+func Dlvsym(handle uintptr, name, version string) (uintptr, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	u := fnDlvsym(handle, name, version)
+	if u == 0 {
+		return 0, dlsymError(fnDlerror())
+	}
+	return u, nil
+}
+
+// Dlmopen is like Dlopen but loads path into the link-map namespace lmid. LM_ID_NEWLM creates
+// a new namespace which only sees the library and its dependencies. This isolates libraries,
+// for example to load two incompatible versions of the same library. The namespace of a handle
+// is returned by Dlinfo with RTLD_DI_LMID. RTLD_GLOBAL is not supported in a new namespace.
+func Dlmopen(lmid int, path string, mode int) (uintptr, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	u := fnDlmopen(lmid, path, mode)
+	if u == 0 {
+		return 0, dlopenError(path, fnDlerror())
+	}
+	return u, nil
+}
+
+//go:linkname dladdr dladdr
+var dladdr uint8
+var dladdrABI0 = uintptr(unsafe.Pointer(&dladdr))
+
+//go:linkname dlinfo dlinfo
+var dlinfo uint8
+var dlinfoABI0 = uintptr(unsafe.Pointer(&dlinfo))
+
+//go:linkname dlvsym dlvsym
+var dlvsym uint8
+var dlvsymABI0 = uintptr(unsafe.Pointer(&dlvsym))
+
+//go:linkname dlmopen dlmopen
+var dlmopen uint8
+var dlmopenABI0 = uintptr(unsafe.Pointer(&dlmopen))
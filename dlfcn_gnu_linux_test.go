@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build !android
+
+package purego_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/ebitengine/purego"
+)
+
+func TestDladdr(t *testing.T) {
+	libc := openLibc(t)
+	puts, err := purego.Dlsym(libc, "puts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := purego.Dladdr(puts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Symbol != "puts" || info.SymbolAddr != puts {
+		t.Errorf("Dladdr returned symbol %q at %#x wanted %q at %#x", info.Symbol, info.SymbolAddr, "puts", puts)
+	}
+	if !strings.HasPrefix(filepath.Base(info.Path), "libc") || info.Base == 0 || info.Base > puts {
+		t.Errorf("Dladdr returned %q at %#x wanted libc below %#x", info.Path, info.Base, puts)
+	}
+	if _, err := purego.Dladdr(0); err == nil {
+		t.Errorf("Dladdr(0) succeeded")
+	}
+}
+
+func TestDlinfo(t *testing.T) {
+	libc := openLibc(t)
+	m, err := purego.DlinfoLinkMap(libc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(filepath.Base(m.Path()), "libc") || !filepath.IsAbs(m.Path()) {
+		t.Errorf("the LinkMap of libc has path %q", m.Path())
+	}
+	puts, err := purego.Dlsym(libc, "puts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := purego.Dladdr(puts); err != nil || info.Path != m.Path() {
+		t.Errorf("Dladdr returned %q, %v wanted %q", info.Path, err, m.Path())
+	}
+}
+
+func TestDlvsym(t *testing.T) {
+	var version string
+	switch runtime.GOARCH {
+	case "amd64":
+		version = "GLIBC_2.2.5"
+	case "arm64":
+		version = "GLIBC_2.17"
+	default:
+		t.Skip("the first glibc version is not known for " + runtime.GOARCH)
+	}
+	libc := openLibc(t)
+	if _, err := purego.Dlvsym(libc, "malloc", version); err != nil {
+		t.Errorf("Dlvsym(malloc, %s) failed: %v", version, err)
+	}
+	if _, err := purego.Dlvsym(libc, "malloc", "GLIBC_0.0"); err == nil {
+		t.Errorf("Dlvsym(malloc, GLIBC_0.0) succeeded")
+	}
+}
+
+func TestDlerror_linux(t *testing.T) {
+	dir := t.TempDir()
+
+	// an ELF header of the other class is enough for the dynamic linker to reject the file
+	header := make([]byte, 64)
+	copy(header, "\x7fELF")
+	header[4] = 1 // ELFCLASS32
+	if unsafe.Sizeof(uintptr(0)) == 4 {
+		header[4] = 2 // ELFCLASS64
+	}
+	header[5], header[6], header[16] = 1, 1, 3 // little endian, version 1, ET_DYN
+	wrongClass := filepath.Join(dir, "libwrongclass.so")
+	if err := os.WriteFile(wrongClass, header, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := purego.Dlopen(wrongClass, purego.RTLD_NOW); !errors.Is(err, purego.ErrWrongELFClass) {
+		t.Errorf("Dlopen(%q) returned %v wanted ErrWrongELFClass", wrongClass, err)
+	}
+
+	undefined := filepath.Join(dir, "libundefined.so")
+	if err := buildSharedLib("CC", undefined, filepath.Join("testdata", "libundefined", "undefined_test.c")); err != nil {
+		t.Fatal(err)
+	}
+	_, err := purego.Dlopen(undefined, purego.RTLD_NOW)
+	if !errors.Is(err, purego.ErrUndefinedSymbol) || !strings.Contains(err.Error(), "purego_undefined") {
+		t.Errorf("Dlopen(%q) returned %v wanted ErrUndefinedSymbol", undefined, err)
+	}
+}
+
+func TestDlmopen(t *testing.T) {
+	libFileName := filepath.Join(t.TempDir(), "libnamespace.so")
+	if err := buildSharedLib("CC", libFileName, filepath.Join("testdata", "namespacetest", "namespace_test.c")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := purego.Dlopen(libFileName, purego.RTLD_NOW|purego.RTLD_NOLOAD); !errors.Is(err, purego.ErrLibraryNotFound) {
+		t.Errorf("Dlopen with RTLD_NOLOAD returned %v before the library was loaded wanted ErrLibraryNotFound", err)
+	}
+	base, err := purego.Dlopen(libFileName, purego.RTLD_NOW|purego.RTLD_LOCAL|purego.RTLD_NODELETE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	isolated, err := purego.Dlmopen(purego.LM_ID_NEWLM, libFileName, purego.RTLD_NOW)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer purego.Dlclose(isolated)
+
+	var incrementBase, incrementIsolated func() int32
+	purego.RegisterLibFunc(&incrementBase, base, "increment")
+	purego.RegisterLibFunc(&incrementIsolated, isolated, "increment")
+	incrementBase()
+	if ret := incrementBase(); ret != 2 {
+		t.Errorf("increment returned %d wanted %d", ret, 2)
+	}
+	if ret := incrementIsolated(); ret != 1 {
+		t.Errorf("increment in the new namespace returned %d wanted %d", ret, 1)
+	}
+	var lmid int
+	if err := purego.Dlinfo(isolated, purego.RTLD_DI_LMID, unsafe.Pointer(&lmid)); err != nil || lmid == purego.LM_ID_BASE {
+		t.Errorf("Dlinfo(RTLD_DI_LMID) returned namespace %d, %v wanted a new namespace", lmid, err)
+	}
+
+	// RTLD_NODELETE keeps the library loaded after Dlclose
+	if err := purego.Dlclose(base); err != nil {
+		t.Fatal(err)
+	}
+	handle, err := purego.Dlopen(libFileName, purego.RTLD_NOW|purego.RTLD_NOLOAD)
+	if err != nil {
+		t.Fatalf("Dlopen with RTLD_NOLOAD failed after Dlclose of a RTLD_NODELETE library: %v", err)
+	}
+	if handle != base {
+		t.Errorf("Dlopen with RTLD_NOLOAD returned %#x wanted %#x", handle, base)
+	}
+	purego.Dlclose(handle)
+}
+
+func TestRTLD_NEXT(t *testing.T) {
+	// the calling object is the executable so the next malloc is the one of libc
+	next, err := purego.Dlsym(purego.RTLD_NEXT, "malloc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	malloc, err := purego.Dlsym(openLibc(t), "malloc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != malloc {
+		t.Errorf("Dlsym(RTLD_NEXT, malloc) returned %#x wanted %#x", next, malloc)
+	}
+}
+
+func openLibc(t *testing.T) uintptr {
+	t.Helper()
+	library, err := getSystemLibrary()
+	if err != nil {
+		t.Fatalf("couldn't get system library: %s", err)
+	}
+	libc, err := purego.Dlopen(library, purego.RTLD_NOW|purego.RTLD_GLOBAL)
+	if err != nil {
+		t.Fatalf("Dlopen(%q) failed: %v", library, err)
+	}
+	return libc
+}
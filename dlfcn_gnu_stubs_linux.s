@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build !android && !cgo && !faketime
+
+#include "textflag.h"
+
+// func dladdr(addr uintptr, info *dlInfo) (ret int32)
+TEXT dladdr(SB), NOSPLIT|NOFRAME, $0-0
+	JMP purego_dladdr(SB)
+	RET
+
+// func dlinfo(handle uintptr, request int32, arg unsafe.Pointer) (ret int32)
+TEXT dlinfo(SB), NOSPLIT|NOFRAME, $0-0
+	JMP purego_dlinfo(SB)
+	RET
+
+// func dlvsym(handle uintptr, symbol *byte, version *byte) (ret uintptr)
+TEXT dlvsym(SB), NOSPLIT|NOFRAME, $0-0
+	JMP purego_dlvsym(SB)
+	RET
+
+// func dlmopen(lmid int, path *byte, mode int) (ret uintptr)
+TEXT dlmopen(SB), NOSPLIT|NOFRAME, $0-0
+	JMP purego_dlmopen(SB)
+	RET
+
+// func dl_iterate_phdr(callback uintptr, data unsafe.Pointer) (ret int32)
+TEXT dl_iterate_phdr(SB), NOSPLIT|NOFRAME, $0-0
+	JMP purego_dl_iterate_phdr(SB)
+	RET
@@ -8,9 +8,20 @@ package purego
 // Source for constants: https://codebrowser.dev/glibc/glibc/bits/dlfcn.h.html
 
 const (
-	RTLD_DEFAULT = 0x00000 // Pseudo-handle for dlsym so search for any loaded symbol
-	RTLD_LAZY    = 0x00001 // Relocations are performed at an implementation-dependent time.
-	RTLD_NOW     = 0x00002 // Relocations are performed when the object is loaded.
-	RTLD_LOCAL   = 0x00000 // All symbols are not made available for relocation processing by other modules.
-	RTLD_GLOBAL  = 0x00100 // All symbols are available for relocation processing of other modules.
+	intSize       = 32 << (^uint(0) >> 63) // 32 or 64
+	RTLD_DEFAULT  = 0x00000                // Pseudo-handle for dlsym so search for any loaded symbol
+	RTLD_NEXT     = 1<<intSize - 1         // Pseudo-handle for dlsym so search for the next occurrence after the calling object
+	RTLD_LAZY     = 0x00001                // Relocations are performed at an implementation-dependent time.
+	RTLD_NOW      = 0x00002                // Relocations are performed when the object is loaded.
+	RTLD_NOLOAD   = 0x00004                // Do not load the object but return its handle if it is already loaded.
+	RTLD_DEEPBIND = 0x00008                // Prefer the symbols of the object to global symbols with the same name.
+	RTLD_LOCAL    = 0x00000                // All symbols are not made available for relocation processing by other modules.
+	RTLD_GLOBAL   = 0x00100                // All symbols are available for relocation processing of other modules.
+	RTLD_NODELETE = 0x01000                // Do not unload the object on Dlclose.
+)
+
+// Namespaces for Dlmopen.
+const (
+	LM_ID_BASE  = 0  // The initial namespace of the program.
+	LM_ID_NEWLM = -1 // A new namespace that only contains the loaded object and its dependencies.
 )
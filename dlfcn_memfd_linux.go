@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build !faketime
+
+package purego
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+// Flags of memfd_create and fcntl for sealing.
+// Source: https://codebrowser.dev/glibc/glibc/sysdeps/unix/sysv/linux/bits/fcntl-linux.h.html
+const (
+	mfdCloexec      = 0x1
+	mfdAllowSealing = 0x2
+
+	fAddSeals   = 1033
+	fSealSeal   = 0x1
+	fSealShrink = 0x2
+	fSealGrow   = 0x4
+	fSealWrite  = 0x8
+)
+
+// rtldNoload is RTLD_NOLOAD of glibc, musl and bionic.
+const rtldNoload = 0x4
+
+var (
+	memfdOnce   sync.Once
+	memfdCreate func(name string, flags uint32) (int32, error)
+
+	memfdMu     sync.Mutex
+	memfdLoaded []*os.File // the descriptors of the libraries loaded by DlopenBytes that may still be loaded
+)
+
+// DlopenBytes loads the shared library in data like Dlopen without writing it to the
+// filesystem. This allows loading a library embedded with //go:embed on a read-only
+// filesystem. The library is copied into a sealed anonymous file from memfd_create that
+// is opened through /proc/self/fd. name is only shown in /proc/self/maps. The file stays
+// open until Dlclose unloads the library.
+//
+// The dynamic linker finds a library loaded this way by its DT_SONAME. To load embedded
+// libraries that depend on each other, load the dependencies first and build them with
+// a soname matching the DT_NEEDED entry of the libraries using them.
+//
+// memfd_create needs Linux 3.17 and glibc 2.27 or musl 1.1.20.
+func DlopenBytes(data []byte, name string, mode int) (uintptr, error) {
+	memfdOnce.Do(func() {
+		if fn, err := Dlsym(RTLD_DEFAULT, "memfd_create"); err == nil {
+			RegisterFunc(&memfdCreate, fn)
+		}
+	})
+	if memfdCreate == nil {
+		return 0, errors.New("purego: DlopenBytes needs memfd_create which the C library doesn't provide")
+	}
+	fd, err := memfdCreate(name, mfdCloexec|mfdAllowSealing)
+	if fd < 0 {
+		return 0, fmt.Errorf("purego: memfd_create failed: %w", err)
+	}
+	f := os.NewFile(uintptr(fd), "memfd:"+name)
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return 0, fmt.Errorf("purego: writing %s failed: %w", name, err)
+	}
+	if _, err := fcntl(f.Fd(), fAddSeals, fSealShrink|fSealGrow|fSealWrite|fSealSeal); err != nil {
+		f.Close()
+		return 0, fmt.Errorf("purego: sealing %s failed: %w", name, err)
+	}
+
+	memfdMu.Lock()
+	defer memfdMu.Unlock()
+	handle, err := Dlopen(memfdPath(f), mode)
+	if err != nil {
+		f.Close()
+		return 0, err
+	}
+	// The dynamic linker returns the library that is already loaded from a path instead of
+	// loading the file again. Keeping the descriptor open keeps its number and with it the
+	// path from being reused for another library while this one is loaded.
+	memfdLoaded = append(memfdLoaded, f)
+	return handle, nil
+}
+
+// releaseMemfds closes the descriptors of the libraries loaded by DlopenBytes that were
+// unloaded. Dlclose calls it after closing a handle, which can also unload dependencies.
+func releaseMemfds() {
+	memfdMu.Lock()
+	defer memfdMu.Unlock()
+	loaded := memfdLoaded[:0]
+	for _, f := range memfdLoaded {
+		if handle, err := Dlopen(memfdPath(f), RTLD_LAZY|rtldNoload); err == nil {
+			// RTLD_NOLOAD still takes a reference
+			closeHandle(handle)
+			loaded = append(loaded, f)
+			continue
+		}
+		f.Close()
+	}
+	for i := len(loaded); i < len(memfdLoaded); i++ {
+		memfdLoaded[i] = nil
+	}
+	memfdLoaded = loaded
+}
+
+func memfdPath(f *os.File) string {
+	return "/proc/self/fd/" + strconv.Itoa(int(f.Fd()))
+}
+
+func fcntl(fd uintptr, cmd int, arg uintptr) (uintptr, error) {
+	r, _, errno := syscall.Syscall(syscall.SYS_FCNTL, fd, uintptr(cmd), arg)
+	if errno != 0 {
+		return 0, errno
+	}
+	return r, nil
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build !android
+
+package purego_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ebitengine/purego"
+)
+
+func TestDlopenBytes(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "libmemfdbase.so")
+	if err := buildSharedLib("CC", base, filepath.Join("testdata", "memfdtest", "base_test.c"), "-Wl,-soname,libmemfdbase.so"); err != nil {
+		t.Fatal(err)
+	}
+	dep := filepath.Join(dir, "libmemfddep.so")
+	if err := buildSharedLib("CC", dep, filepath.Join("testdata", "memfdtest", "dep_test.c"), "-L"+dir, "-lmemfdbase"); err != nil {
+		t.Fatal(err)
+	}
+	baseData, err := os.ReadFile(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	depData, err := os.ReadFile(dep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the libraries must not be found on disk
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := purego.DlopenBytes(depData, "libmemfddep.so", purego.RTLD_NOW); !errors.Is(err, purego.ErrLibraryNotFound) {
+		t.Errorf("DlopenBytes of a library with a missing dependency returned %v wanted ErrLibraryNotFound", err)
+	}
+	baseLib, err := purego.DlopenBytes(baseData, "libmemfdbase.so", purego.RTLD_NOW|purego.RTLD_LOCAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	depLib, err := purego.DlopenBytes(depData, "libmemfddep.so", purego.RTLD_NOW|purego.RTLD_LOCAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if depLib == baseLib {
+		t.Fatalf("DlopenBytes returned the handle of libmemfdbase.so for libmemfddep.so")
+	}
+
+	var depValue func() int32
+	purego.RegisterLibFunc(&depValue, depLib, "dep_value")
+	if got := depValue(); got != 42 {
+		t.Errorf("dep_value returned %d wanted %d", got, 42)
+	}
+
+	if _, err := purego.DlopenBytes([]byte("not a library"), "invalid", purego.RTLD_NOW); err == nil {
+		t.Errorf("DlopenBytes of invalid data succeeded")
+	}
+	if n := openMemfds(t, "libmemfd"); n != 2 {
+		t.Errorf("%d descriptors are open for the 2 loaded libraries", n)
+	}
+
+	// libmemfdbase.so stays loaded until libmemfddep.so which depends on it is unloaded
+	if err := purego.Dlclose(baseLib); err != nil {
+		t.Fatal(err)
+	}
+	if n := openMemfds(t, "libmemfd"); n != 2 {
+		t.Errorf("%d descriptors are open after closing libmemfdbase.so wanted 2", n)
+	}
+	if err := purego.Dlclose(depLib); err != nil {
+		t.Fatal(err)
+	}
+	if n := openMemfds(t, "libmemfd"); n != 0 {
+		t.Errorf("%d descriptors are open after the libraries were unloaded wanted 0", n)
+	}
+	if n := openMemfds(t, "invalid"); n != 0 {
+		t.Errorf("%d descriptors are open after loading invalid data wanted 0", n)
+	}
+
+	// loading again from a descriptor number that was used before loads the new file
+	baseLib, err = purego.DlopenBytes(baseData, "libmemfdbase.so", purego.RTLD_NOW|purego.RTLD_LOCAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer purego.Dlclose(baseLib)
+	var baseValue func() int32
+	purego.RegisterLibFunc(&baseValue, baseLib, "base_value")
+	if got := baseValue(); got != 40 {
+		t.Errorf("base_value returned %d wanted %d", got, 40)
+	}
+}
+
+// openMemfds returns the number of open descriptors of memfd files whose name starts with prefix.
+func openMemfds(t *testing.T, prefix string) int {
+	t.Helper()
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	for _, e := range entries {
+		if target, err := os.Readlink(filepath.Join("/proc/self/fd", e.Name())); err == nil && strings.HasPrefix(target, "/memfd:"+prefix) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,9 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build darwin || freebsd || netbsd
+
+package purego
+
+// releaseMemfds does nothing as DlopenBytes is only available on Linux.
+func releaseMemfds() {}
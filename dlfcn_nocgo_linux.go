@@ -12,6 +12,11 @@ package purego
 //go:cgo_import_dynamic purego_dlsym dlsym "libdl.so.2"
 //go:cgo_import_dynamic purego_dlerror dlerror "libdl.so.2"
 //go:cgo_import_dynamic purego_dlclose dlclose "libdl.so.2"
+//go:cgo_import_dynamic purego_dladdr dladdr "libdl.so.2"
+//go:cgo_import_dynamic purego_dlinfo dlinfo "libdl.so.2"
+//go:cgo_import_dynamic purego_dlvsym dlvsym "libdl.so.2"
+//go:cgo_import_dynamic purego_dlmopen dlmopen "libdl.so.2"
+//go:cgo_import_dynamic purego_dl_iterate_phdr dl_iterate_phdr "libc.so.6"
 
 // on amd64 we don't need the following line - on 386 we do...
 // anyway - with those lines the output is better (but doesn't matter) - without it on amd64 we get multiple DT_NEEDED with "libc.so.6" etc
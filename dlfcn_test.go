@@ -6,8 +6,10 @@
 package purego_test
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"unsafe"
 
@@ -20,6 +22,23 @@ func TestSimpleDlsym(t *testing.T) {
 	}
 }
 
+func TestDlerror(t *testing.T) {
+	const missing = "/purego/nonexistent/libmissing.so"
+	_, err := purego.Dlopen(missing, purego.RTLD_NOW)
+	if !errors.Is(err, purego.ErrLibraryNotFound) {
+		t.Errorf("Dlopen(%q) returned %v wanted ErrLibraryNotFound", missing, err)
+	}
+	var dlerr purego.Dlerror
+	if !errors.As(err, &dlerr) || !strings.Contains(dlerr.Error(), "libmissing") {
+		t.Errorf("Dlopen(%q) returned %v wanted the message of dlerror", missing, err)
+	}
+
+	_, err = purego.Dlsym(purego.RTLD_DEFAULT, "purego_missing_symbol")
+	if !errors.Is(err, purego.ErrSymbolNotFound) || !strings.Contains(err.Error(), "purego_missing_symbol") {
+		t.Errorf("Dlsym returned %v wanted ErrSymbolNotFound with the message of dlerror", err)
+	}
+}
+
 func TestNestedDlopenCall(t *testing.T) {
 	libFileName := filepath.Join(t.TempDir(), "libdlnested.so")
 	t.Logf("Build %v", libFileName)
@@ -0,0 +1,438 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build darwin || freebsd || linux || netbsd
+
+package purego
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// LibraryOption configures how FindLibrary searches for a library.
+type LibraryOption func(*libraryOptions)
+
+type libraryOptions struct {
+	rpath      []string
+	minVersion int
+	maxVersion int // -1 if any major version is accepted
+}
+
+// WithRPath adds directories that are searched before LD_LIBRARY_PATH
+// like the DT_RPATH of an executable. "$ORIGIN" and "${ORIGIN}" are replaced
+// with the directory that contains the executable of the current process.
+func WithRPath(dirs ...string) LibraryOption {
+	return func(o *libraryOptions) {
+		o.rpath = append(o.rpath, dirs...)
+	}
+}
+
+// WithVersions only accepts the major versions min through max of a library.
+// For example, WithVersions(1, 2) accepts libfoo.so.1 and libfoo.so.2 but neither
+// libfoo.so.3 nor the unversioned libfoo.so.
+func WithVersions(min, max int) LibraryOption {
+	return func(o *libraryOptions) {
+		o.minVersion, o.maxVersion = min, max
+	}
+}
+
+// LibraryNotFoundError is returned by FindLibrary if none of the candidates can be loaded.
+type LibraryNotFoundError struct {
+	Name  string   // the name passed to FindLibrary
+	Tried []string // the candidates in the order they were tried
+}
+
+func (e *LibraryNotFoundError) Error() string {
+	if len(e.Tried) == 0 {
+		return fmt.Sprintf("purego: library %q not found", e.Name)
+	}
+	return fmt.Sprintf("purego: library %q not found, tried %s", e.Name, strings.Join(e.Tried, ", "))
+}
+
+// Is reports whether target is ErrLibraryNotFound.
+func (e *LibraryNotFoundError) Is(target error) bool {
+	return target == ErrLibraryNotFound
+}
+
+// FindLibrary returns the path of the shared library name that can be passed to Dlopen.
+//
+// name is either a path, which is returned if it can be loaded, a file name like "libfoo.so.1"
+// or a bare name like "foo" or "libfoo". Bare names match libfoo.so and libfoo.so.N
+// (libfoo.dylib and libfoo.N.dylib on macOS) where the highest major version is preferred.
+// The directories are searched in the order the dynamic linker uses:
+//
+//   - the directories given with WithRPath
+//   - LD_LIBRARY_PATH (DYLD_LIBRARY_PATH on macOS)
+//   - /etc/ld.so.cache on Linux
+//   - the default library directories of the system
+//
+// Files built for a different architecture or ELF class, and linker scripts like glibc's
+// libc.so, are skipped. If nothing is found, it returns a *LibraryNotFoundError listing every
+// candidate that was tried.
+//
+// On macOS, system libraries only exist in the dyld shared cache and are not found on disk.
+// Pass their install name like "/usr/lib/libSystem.B.dylib" to Dlopen directly.
+func FindLibrary(name string, opts ...LibraryOption) (string, error) {
+	o := libraryOptions{maxVersion: -1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	f := libraryFinder{name: name, opts: &o, seen: map[string]bool{}}
+	if strings.Contains(name, "/") {
+		if f.try(name) {
+			return name, nil
+		}
+		return "", f.notFound()
+	}
+	var dirs []string
+	for _, dir := range o.rpath {
+		dirs = append(dirs, expandOrigin(dir))
+	}
+	env := "LD_LIBRARY_PATH"
+	if runtime.GOOS == "darwin" {
+		env = "DYLD_LIBRARY_PATH"
+	}
+	for _, dir := range filepath.SplitList(os.Getenv(env)) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	if path, ok := f.searchDirs(dirs); ok {
+		return path, nil
+	}
+	if runtime.GOOS == "linux" {
+		if path, ok := f.searchCache("/etc/ld.so.cache"); ok {
+			return path, nil
+		}
+	}
+	if path, ok := f.searchDirs(defaultLibraryDirs()); ok {
+		return path, nil
+	}
+	return "", f.notFound()
+}
+
+type libraryFinder struct {
+	name  string
+	opts  *libraryOptions
+	tried []string
+	seen  map[string]bool
+}
+
+func (f *libraryFinder) notFound() error {
+	return &LibraryNotFoundError{Name: f.name, Tried: f.tried}
+}
+
+// try records path as a candidate and reports whether it can be loaded.
+func (f *libraryFinder) try(path string) bool {
+	if f.seen[path] {
+		return false
+	}
+	f.seen[path] = true
+	f.tried = append(f.tried, path)
+	return isLoadableLibrary(path)
+}
+
+// base returns the file name of the library without the suffix and version
+// or an empty string if name already is a complete file name.
+func (f *libraryFinder) base() string {
+	if strings.Contains(f.name, ".so") || strings.HasSuffix(f.name, ".dylib") {
+		return ""
+	}
+	if strings.HasPrefix(f.name, "lib") {
+		return f.name
+	}
+	return "lib" + f.name
+}
+
+// version returns the major version of the file name of a library called base
+// or -1 if it is unversioned. ok is false if file isn't a library called base.
+func (f *libraryFinder) version(base, file string) (version int, ok bool) {
+	var v string
+	if runtime.GOOS == "darwin" {
+		if file == base+".dylib" {
+			return -1, true
+		}
+		if !strings.HasPrefix(file, base+".") || !strings.HasSuffix(file, ".dylib") {
+			return 0, false
+		}
+		v = strings.TrimSuffix(strings.TrimPrefix(file, base+"."), ".dylib")
+	} else {
+		if file == base+".so" {
+			return -1, true
+		}
+		if !strings.HasPrefix(file, base+".so.") {
+			return 0, false
+		}
+		v = strings.TrimPrefix(file, base+".so.")
+	}
+	major, _, _ := strings.Cut(v, ".")
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// accepts reports whether version is in the range given with WithVersions.
+func (f *libraryFinder) accepts(version int) bool {
+	if f.opts.maxVersion < 0 {
+		return true
+	}
+	return version >= f.opts.minVersion && version <= f.opts.maxVersion
+}
+
+// candidates returns the file names in files that match the library
+// ordered by preference.
+func (f *libraryFinder) candidates(files []string) []string {
+	base := f.base()
+	if base == "" {
+		for _, file := range files {
+			if file == f.name {
+				return []string{file}
+			}
+		}
+		return nil
+	}
+	type candidate struct {
+		file    string
+		version int
+	}
+	var cs []candidate
+	for _, file := range files {
+		if v, ok := f.version(base, file); ok && f.accepts(v) {
+			cs = append(cs, candidate{file, v})
+		}
+	}
+	sort.SliceStable(cs, func(i, j int) bool {
+		// the unversioned name is what the linker uses for -lfoo
+		if cs[i].version < 0 || cs[j].version < 0 {
+			return cs[i].version < 0 && cs[j].version >= 0
+		}
+		return cs[i].version > cs[j].version
+	})
+	names := make([]string, len(cs))
+	for i, c := range cs {
+		names[i] = c.file
+	}
+	return names
+}
+
+// pattern returns how the library is named in the list of tried candidates if a directory has no match.
+func (f *libraryFinder) pattern() string {
+	base := f.base()
+	switch {
+	case base == "":
+		return f.name
+	case runtime.GOOS == "darwin":
+		return base + "*.dylib"
+	default:
+		return base + ".so*"
+	}
+}
+
+func (f *libraryFinder) searchDirs(dirs []string) (string, bool) {
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		files := make([]string, len(entries))
+		for i, e := range entries {
+			files[i] = e.Name()
+		}
+		names := f.candidates(files)
+		if len(names) == 0 {
+			f.tried = append(f.tried, filepath.Join(dir, f.pattern()))
+			continue
+		}
+		for _, file := range names {
+			if path := filepath.Join(dir, file); f.try(path) {
+				return path, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (f *libraryFinder) searchCache(cache string) (string, bool) {
+	entries, err := readLdCache(cache)
+	if err != nil {
+		return "", false
+	}
+	files := make([]string, 0, len(entries))
+	paths := make(map[string][]string, len(entries))
+	for _, e := range entries {
+		if _, ok := paths[e.key]; !ok {
+			files = append(files, e.key)
+		}
+		paths[e.key] = append(paths[e.key], e.value)
+	}
+	for _, file := range f.candidates(files) {
+		// the cache has an entry for every architecture the system has libraries for
+		for _, path := range paths[file] {
+			if f.try(path) {
+				return path, true
+			}
+		}
+	}
+	return "", false
+}
+
+// expandOrigin replaces $ORIGIN in dir with the directory of the executable.
+func expandOrigin(dir string) string {
+	if !strings.Contains(dir, "$ORIGIN") && !strings.Contains(dir, "${ORIGIN}") {
+		return dir
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return dir
+	}
+	if p, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = p
+	}
+	origin := filepath.Dir(exe)
+	dir = strings.ReplaceAll(dir, "${ORIGIN}", origin)
+	return strings.ReplaceAll(dir, "$ORIGIN", origin)
+}
+
+// defaultLibraryDirs returns the directories the dynamic linker searches last.
+func defaultLibraryDirs() []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"/usr/local/lib", "/usr/lib", "/opt/homebrew/lib"}
+	case "freebsd":
+		return []string{"/lib", "/usr/lib", "/usr/local/lib"}
+	case "netbsd":
+		return []string{"/usr/lib", "/usr/pkg/lib", "/usr/local/lib"}
+	}
+	var dirs []string
+	if triplet, ok := multiarchTriplets[runtime.GOARCH]; ok {
+		dirs = append(dirs, "/lib/"+triplet, "/usr/lib/"+triplet)
+	}
+	if runtime.GOARCH != "386" && runtime.GOARCH != "arm" {
+		dirs = append(dirs, "/lib64", "/usr/lib64")
+	}
+	return append(dirs, "/lib", "/usr/lib", "/usr/local/lib")
+}
+
+// multiarchTriplets are the names of the Debian multiarch directories of each GOARCH.
+var multiarchTriplets = map[string]string{
+	"386":     "i386-linux-gnu",
+	"amd64":   "x86_64-linux-gnu",
+	"arm":     "arm-linux-gnueabihf",
+	"arm64":   "aarch64-linux-gnu",
+	"loong64": "loongarch64-linux-gnu",
+	"ppc64le": "powerpc64le-linux-gnu",
+	"riscv64": "riscv64-linux-gnu",
+	"s390x":   "s390x-linux-gnu",
+}
+
+// elfMachines is the ELF machine of each GOARCH.
+var elfMachines = map[string]elf.Machine{
+	"386":     elf.EM_386,
+	"amd64":   elf.EM_X86_64,
+	"arm":     elf.EM_ARM,
+	"arm64":   elf.EM_AARCH64,
+	"loong64": 258, // EM_LOONGARCH
+	"ppc64le": elf.EM_PPC64,
+	"riscv64": elf.EM_RISCV,
+	"s390x":   elf.EM_S390,
+}
+
+// isLoadableLibrary reports whether path is a shared library for the current process.
+func isLoadableLibrary(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "darwin" {
+		return true
+	}
+	file, err := elf.Open(path)
+	if err != nil {
+		// not an ELF file like the linker script libc.so
+		return false
+	}
+	defer file.Close()
+	class := elf.ELFCLASS64
+	if unsafe.Sizeof(uintptr(0)) == 4 {
+		class = elf.ELFCLASS32
+	}
+	if file.Class != class || file.Type != elf.ET_DYN {
+		return false
+	}
+	if m, ok := elfMachines[runtime.GOARCH]; ok && file.Machine != m {
+		return false
+	}
+	return true
+}
+
+type ldCacheEntry struct {
+	key   string // the soname like libc.so.6
+	value string // the path of the library
+}
+
+// readLdCache parses the entries of the glibc ld.so.cache in the new format
+// which follows the old format in caches that contain both.
+func readLdCache(path string) ([]ldCacheEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	const magic = "glibc-ld.so.cache1.1"
+	start := bytes.Index(data, []byte(magic))
+	if start < 0 {
+		return nil, fmt.Errorf("purego: %s has an unknown format", path)
+	}
+	// struct cache_file_new {
+	//	char magic[17], version[3];
+	//	uint32_t nlibs, len_strings;
+	//	uint8_t flags, padding[3];
+	//	uint32_t extension_offset, unused[3];
+	//	struct file_entry_new { int32_t flags; uint32_t key, value, osversion; uint64_t hwcap; } libs[];
+	// }
+	const headerSize, entrySize = 48, 24
+	cache := data[start:]
+	if len(cache) < headerSize {
+		return nil, fmt.Errorf("purego: %s is truncated", path)
+	}
+	// the cache is written in the byte order of the system
+	order := binary.ByteOrder(binary.LittleEndian)
+	if runtime.GOARCH == "s390x" {
+		order = binary.BigEndian
+	}
+	nlibs := int(order.Uint32(cache[20:]))
+	if nlibs < 0 || headerSize+nlibs*entrySize > len(cache) {
+		return nil, fmt.Errorf("purego: %s is truncated", path)
+	}
+	str := func(off uint32) string {
+		if int(off) >= len(cache) {
+			return ""
+		}
+		s := cache[off:]
+		if i := bytes.IndexByte(s, 0); i >= 0 {
+			s = s[:i]
+		}
+		return string(s)
+	}
+	entries := make([]ldCacheEntry, 0, nlibs)
+	for i := 0; i < nlibs; i++ {
+		e := cache[headerSize+i*entrySize:]
+		// string offsets are relative to the start of the new format
+		entries = append(entries, ldCacheEntry{
+			key:   str(order.Uint32(e[4:])),
+			value: str(order.Uint32(e[8:])),
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build linux
+
+package purego_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ebitengine/purego"
+)
+
+func TestFindLibrary(t *testing.T) {
+	libc, err := purego.FindLibrary("c")
+	if err != nil {
+		t.Fatalf("FindLibrary failed: %v", err)
+	}
+	if !strings.HasPrefix(filepath.Base(libc), "libc.so.") {
+		t.Errorf("FindLibrary returned %q wanted a libc.so.N", libc)
+	}
+	handle, err := purego.Dlopen(libc, purego.RTLD_NOW)
+	if err != nil {
+		t.Fatalf("Dlopen(%q) failed: %v", libc, err)
+	}
+	defer purego.Dlclose(handle)
+
+	// copies of libc stand in for the versions of a library
+	data, err := os.ReadFile(libc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"libpuregotest.so.1", "libpuregotest.so.3"} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a linker script like glibc's libc.so isn't a library
+	if err := os.WriteFile(filepath.Join(dir, "libpuregotest.so"), []byte("GROUP ( libpuregotest.so.3 )\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := purego.FindLibrary("puregotest", purego.WithRPath(dir)); err != nil || got != filepath.Join(dir, "libpuregotest.so.3") {
+		t.Errorf("FindLibrary returned %q, %v wanted libpuregotest.so.3", got, err)
+	}
+	if got, err := purego.FindLibrary("libpuregotest", purego.WithRPath(dir), purego.WithVersions(1, 2)); err != nil || got != filepath.Join(dir, "libpuregotest.so.1") {
+		t.Errorf("FindLibrary returned %q, %v wanted libpuregotest.so.1", got, err)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		t.Fatal(err)
+	}
+	rel, err := filepath.Rel(filepath.Dir(exe), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := purego.FindLibrary("libpuregotest.so.1", purego.WithRPath("$ORIGIN/"+rel)); err != nil || got != filepath.Join(dir, "libpuregotest.so.1") {
+		t.Errorf("FindLibrary returned %q, %v wanted libpuregotest.so.1 relative to $ORIGIN", got, err)
+	}
+
+	_, err = purego.FindLibrary("puregotest", purego.WithRPath(dir), purego.WithVersions(4, 5))
+	var notFound *purego.LibraryNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("FindLibrary returned %v wanted a *LibraryNotFoundError", err)
+	}
+	if len(notFound.Tried) == 0 || notFound.Tried[0] != filepath.Join(dir, "libpuregotest.so*") {
+		t.Errorf("FindLibrary tried %q wanted %s first", notFound.Tried, filepath.Join(dir, "libpuregotest.so*"))
+	}
+	if !errors.Is(err, purego.ErrLibraryNotFound) {
+		t.Errorf("FindLibrary returned %v which is not ErrLibraryNotFound", err)
+	}
+}
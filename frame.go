@@ -0,0 +1,315 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build darwin || freebsd || linux || netbsd || windows
+
+package purego
+
+import (
+	"math"
+	"reflect"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/ebitengine/purego/internal/strings"
+)
+
+var framePool = sync.Pool{New: func() any {
+	return new(Frame)
+}}
+
+// Frame holds the arguments and results of one call to a C function. The methods that add
+// arguments place them in the registers and on the stack like the C calling convention of the
+// platform does. RegisterFunc uses a Frame for every call that it has no precomputed plan for
+// and the wrappers that cmd/purego-gen generates use it directly, so both agree on where each
+// argument goes.
+//
+// Frame is meant for generated code. The arguments must be added in the order of the C
+// parameters and like with RegisterFunc a mismatch with the C prototype is undefined behavior.
+// Pointers passed to Pointer must be kept alive by the caller until Call returns. Adding more
+// arguments than fit in the registers and stack slots purego passes panics like SyscallN.
+//
+// Frame is experimental. Unlike the rest of the API it is not covered by the compatibility
+// promise of purego releases and may change or move when the calling conventions it models
+// need it to. Code using it should be generated with the cmd/purego-gen of the purego version
+// it is built with and be regenerated after upgrading. Write bindings by hand with RegisterFunc.
+//
+// Integers, floats, pointers and strings are added without reflection. Structs and complex
+// numbers still go through reflection to classify their fields, see Struct.
+type Frame struct {
+	args      syscall15Args
+	numInts   int
+	numFloats int
+	numStack  int     // the number of 8 byte stack slots in use
+	stackSize uintptr // the number of bytes of the stack in use when packing by natural alignment
+	variadic  bool
+
+	strs    [maxArgs]*byte // the C strings added by String
+	numStrs int
+
+	keepAlive  []any
+	result     unsafe.Pointer // where Call copies a struct result to
+	resultType reflect.Type
+}
+
+// NewFrame returns an empty Frame from a pool for a call to the C function fn.
+// Free returns it to the pool.
+func NewFrame(fn uintptr) *Frame {
+	f := framePool.Get().(*Frame)
+	f.args.fn = fn
+	return f
+}
+
+// Free clears f and returns it to the pool. f must not be used afterward.
+func (f *Frame) Free() {
+	*f = Frame{}
+	framePool.Put(f)
+}
+
+// numberedRegisters reports whether each argument takes the register or stack slot of its
+// position no matter its kind. This is the case for Windows except on arm64.
+func numberedRegisters() bool {
+	return runtime.GOOS == "windows" && runtime.GOARCH != "arm64"
+}
+
+// packedStack reports whether arguments on the stack are packed by their natural alignment
+// instead of taking 8 byte slots each like on Apple arm64.
+func packedStack() bool {
+	return runtime.GOOS == "darwin" && runtime.GOARCH == "arm64"
+}
+
+// word returns the argument word at index i of the integer registers followed by the stack.
+func (f *Frame) word(i int) *uintptr {
+	if i >= maxArgs {
+		panic("purego: too many arguments")
+	}
+	return &f.args.ints()[i]
+}
+
+func (f *Frame) addStack(x uintptr) {
+	*f.word(numOfIntegerRegisters() + f.numStack) = x
+	f.numStack++
+	f.stackSize = uintptr(f.numStack) * 8
+}
+
+// pushStack places the size bytes of x on the stack at the next offset aligned to size.
+func (f *Frame) pushStack(x, size uintptr) {
+	if max := uintptr(f.numStack) * 8; f.stackSize > max {
+		// addStruct took back slots that were in use
+		f.stackSize = max
+	}
+	off := (f.stackSize + size - 1) &^ (size - 1)
+	word := f.word(numOfIntegerRegisters() + int(off/8))
+	p := unsafe.Add(unsafe.Pointer(word), off%8)
+	switch size {
+	case 1:
+		*(*uint8)(p) = uint8(x)
+	case 2:
+		*(*uint16)(p) = uint16(x)
+	case 4:
+		*(*uint32)(p) = uint32(x)
+	default:
+		*(*uint64)(p) = uint64(x)
+	}
+	f.stackSize = off + size
+	f.numStack = int((f.stackSize + 7) / 8)
+}
+
+func (f *Frame) addInt(x uintptr) {
+	switch {
+	case numberedRegisters():
+		*f.word(f.numStack) = x
+		f.numStack++
+	case f.numInts >= numOfIntegerRegisters() || f.variadic && packedStack():
+		// Apple arm64 passes all variadic arguments on the stack in 8 byte slots
+		f.addStack(x)
+	default:
+		f.args.ints()[f.numInts] = x
+		f.numInts++
+	}
+}
+
+func (f *Frame) addFloat(x uintptr) {
+	switch {
+	case numberedRegisters():
+		f.addInt(x)
+	case f.variadic && runtime.GOARCH == "loong64":
+		// LoongArch passes variadic floats in the integer registers
+		f.addInt(x)
+	case f.numFloats >= numOfFloatRegisters || f.variadic && packedStack():
+		f.addStack(x)
+	default:
+		f.args.floats()[f.numFloats] = x
+		f.numFloats++
+	}
+}
+
+// addValue adds v which is passed in registers or on the stack by value.
+func (f *Frame) addValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		f.String(v.String())
+	case reflect.Uintptr, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f.Int(uintptr(v.Uint()), v.Type().Size())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f.Int(uintptr(v.Int()), v.Type().Size())
+	case reflect.Bool:
+		f.Bool(v.Bool())
+	case reflect.Float32:
+		f.Float32(float32(v.Float()))
+	case reflect.Float64:
+		f.Float64(v.Float())
+	default:
+		f.keepAlive = addValue(v, f.keepAlive, f.addInt, f.addFloat, f.addStack, &f.numInts, &f.numFloats, &f.numStack)
+	}
+}
+
+// Int adds an integer or pointer argument. size is the size of the C type in bytes which
+// decides its place on the stack of Apple arm64. Signed values must be sign extended.
+func (f *Frame) Int(x, size uintptr) {
+	if packedStack() && !f.variadic && f.numInts >= numOfIntegerRegisters() {
+		f.pushStack(x, size)
+		return
+	}
+	f.addInt(x)
+}
+
+// Bool adds a bool argument.
+func (f *Frame) Bool(b bool) {
+	var x uintptr
+	if b {
+		x = 1
+	}
+	f.Int(x, 1)
+}
+
+// Pointer adds a pointer argument.
+func (f *Frame) Pointer(p unsafe.Pointer) {
+	f.Int(uintptr(p), unsafe.Sizeof(p))
+}
+
+// Float32 adds a float argument.
+func (f *Frame) Float32(x float32) {
+	if packedStack() && !f.variadic && f.numFloats >= numOfFloatRegisters {
+		f.pushStack(uintptr(math.Float32bits(x)), 4)
+		return
+	}
+	f.addFloat(uintptr(math.Float32bits(x)))
+}
+
+// Float64 adds a double argument.
+func (f *Frame) Float64(x float64) {
+	if packedStack() && !f.variadic && f.numFloats >= numOfFloatRegisters {
+		f.pushStack(uintptr(math.Float64bits(x)), 8)
+		return
+	}
+	f.addFloat(uintptr(math.Float64bits(x)))
+}
+
+// String adds a copy of s as a null-terminated C string that stays alive until Free.
+func (f *Frame) String(s string) {
+	ptr := strings.CString(s)
+	if f.numStrs < len(f.strs) {
+		f.strs[f.numStrs] = ptr
+		f.numStrs++
+	} else {
+		f.keepAlive = append(f.keepAlive, ptr)
+	}
+	f.Int(uintptr(unsafe.Pointer(ptr)), unsafe.Sizeof(ptr))
+}
+
+// Variadic marks the start of the variadic arguments of a C function like printf.
+// The arguments after it must already have the C default argument promotions applied.
+func (f *Frame) Variadic() {
+	f.variadic = true
+}
+
+// Struct adds the struct or complex number of type ty at p passed by value.
+// Unlike the other methods it uses reflection to place the fields like RegisterFunc does.
+func (f *Frame) Struct(p unsafe.Pointer, ty reflect.Type) {
+	if isComplex(ty) {
+		ty = complexStruct(ty)
+	}
+	f.keepAlive = addStruct(reflect.NewAt(ty, p).Elem(), &f.numInts, &f.numFloats, &f.numStack, f.addInt, f.addFloat, f.addStack, f.keepAlive)
+}
+
+// StructResult makes Call store the struct or complex number of type ty that the C function
+// returns at p. It must be called before any argument is added. Like Struct it uses reflection.
+func (f *Frame) StructResult(p unsafe.Pointer, ty reflect.Type) {
+	f.result, f.resultType = p, ty
+	if isComplex(ty) || ty.Size() <= maxRegAllocStructSize {
+		return
+	}
+	// the C function writes the struct to the memory the hidden argument points to
+	switch runtime.GOARCH {
+	case "amd64", "loong64":
+		f.addInt(uintptr(p))
+	case "arm64":
+		if isAllFloats, numFields := isAllSameFloat(ty); !isAllFloats || numFields > 4 {
+			f.args.arm64_r8 = uintptr(p)
+		}
+	}
+}
+
+// CaptureErrno makes Call save errno after the C function returns for Errno.
+func (f *Frame) CaptureErrno() {
+	f.args.errnoFn = errnoLocation
+}
+
+// Call calls the C function with the arguments added so far.
+func (f *Frame) Call() {
+	f.call()
+	if f.resultType != nil {
+		ty := f.resultType
+		if isComplex(ty) {
+			ty = complexStruct(ty)
+		}
+		reflect.NewAt(ty, f.result).Elem().Set(getStruct(ty, f.args))
+	}
+	runtime.KeepAlive(f)
+}
+
+func (f *Frame) call() {
+	if runtime.GOARCH == "arm64" || runtime.GOARCH == "loong64" || runtime.GOOS != "windows" {
+		// Use the normal arm64 calling convention even on Windows
+		f.args.nstack = uintptr(f.numStack)
+		runtime_cgocall(syscall15XABI0, unsafe.Pointer(&f.args))
+	} else {
+		// This is a fallback for Windows amd64, 386, and arm. Note this may not support floats
+		f.args.a1, f.args.a2, f.args.err = syscall_syscallN(f.args.fn, f.args.ints()[:f.numInts+f.numStack])
+		f.args.f1 = f.args.a2 // on amd64 a2 stores the float return. On 32bit platforms floats aren't support
+	}
+}
+
+// IntResult returns the integer or pointer result of the call.
+func (f *Frame) IntResult() uintptr {
+	return f.args.a1
+}
+
+// PointerResult returns the pointer result of the call.
+func (f *Frame) PointerResult() unsafe.Pointer {
+	// We take the address and then dereference it to trick go vet from creating a possible miss-use of unsafe.Pointer
+	return *(*unsafe.Pointer)(unsafe.Pointer(&f.args.a1))
+}
+
+// Float32Result returns the float result of the call.
+func (f *Frame) Float32Result() float32 {
+	return math.Float32frombits(uint32(f.args.f1))
+}
+
+// Float64Result returns the double result of the call.
+func (f *Frame) Float64Result() float64 {
+	return math.Float64frombits(uint64(f.args.f1))
+}
+
+// StringResult returns a copy of the null-terminated C string the call returned.
+func (f *Frame) StringResult() string {
+	return strings.GoString(f.args.a1)
+}
+
+// Errno returns errno after the call if CaptureErrno was called before it.
+func (f *Frame) Errno() syscall.Errno {
+	return syscall.Errno(f.args.err)
+}
@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build darwin || (linux && (amd64 || arm64 || loong64))
+
+package purego_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/ebitengine/purego"
+	"github.com/ebitengine/purego/internal/load"
+)
+
+func TestFrame(t *testing.T) {
+	libFileName := filepath.Join(t.TempDir(), "abitest.so")
+	if err := buildSharedLib("CC", libFileName, filepath.Join("testdata", "abitest", "abi_test.c")); err != nil {
+		t.Fatal(err)
+	}
+	lib, err := load.OpenLibrary(libFileName)
+	if err != nil {
+		t.Fatalf("Dlopen(%q) failed: %v", libFileName, err)
+	}
+	defer load.CloseLibrary(lib)
+
+	stackUint8, err := load.OpenSymbol(lib, "stack_uint8_t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	callStackUint8 := func() uint32 {
+		f := purego.NewFrame(stackUint8)
+		defer f.Free()
+		for _, x := range []uint32{256, 512, 4, 8, 16, 32, 64, 128} {
+			f.Int(uintptr(x), 4)
+		}
+		f.Int(1, 1)
+		f.Int(2, 1)
+		f.Int(1024, 4)
+		f.Call()
+		return uint32(f.IntResult())
+	}
+	if ret := callStackUint8(); ret != 2047 {
+		t.Errorf("stack_uint8_t returned %d wanted %d", ret, 2047)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { callStackUint8() }); allocs != 0 {
+		t.Errorf("calling stack_uint8_t allocated %v times wanted 0", allocs)
+	}
+
+	stackDoubles, err := load.OpenSymbol(lib, "stack_10_intptr_t_10_doubles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := purego.NewFrame(stackDoubles)
+	for i := 1; i <= 10; i++ {
+		f.Int(uintptr(i), unsafe.Sizeof(uintptr(0)))
+	}
+	for i := 1; i <= 10; i++ {
+		f.Float64(float64(i))
+	}
+	f.Call()
+	if ret := f.Float64Result(); ret != 770 {
+		t.Errorf("stack_10_intptr_t_10_doubles returned %v wanted %v", ret, 770)
+	}
+	f.Free()
+}
+
+func TestFrame_struct(t *testing.T) {
+	libFileName := filepath.Join(t.TempDir(), "structtest.so")
+	if err := buildSharedLib("CC", libFileName, filepath.Join("testdata", "structtest", "struct_test.c"), filepath.Join("testdata", "structtest", "structreturn_test.c")); err != nil {
+		t.Fatal(err)
+	}
+	lib, err := load.OpenLibrary(libFileName)
+	if err != nil {
+		t.Fatalf("Dlopen(%q) failed: %v", libFileName, err)
+	}
+	defer load.CloseLibrary(lib)
+
+	{
+		type GreaterThan16Bytes struct {
+			x, y, z *int64
+		}
+		var x, y, z int64 = 0xEF, 0xBE00, 0xDEAD0000
+		sym, err := load.OpenSymbol(lib, "AfterRegisters")
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := purego.NewFrame(sym)
+		for _, x := range []uintptr{0xD0000000, 0xE000000, 0xA00000, 0xD0000, 0xB000, 0xE00, 0xE0, 0xF} {
+			f.Int(x, 8)
+		}
+		g := GreaterThan16Bytes{&x, &y, &z}
+		f.Struct(unsafe.Pointer(&g), reflect.TypeOf(g))
+		f.Call()
+		if ret := f.IntResult(); ret != 0xdeadbeef {
+			t.Errorf("AfterRegisters returned %#x wanted %#x", ret, 0xdeadbeef)
+		}
+		f.Free()
+	}
+	{
+		type ThreeLongs struct{ a, b, c int64 }
+		sym, err := load.OpenSymbol(lib, "ReturnThreeLongs")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ret ThreeLongs
+		f := purego.NewFrame(sym)
+		f.StructResult(unsafe.Pointer(&ret), reflect.TypeOf(ret))
+		f.Int(1, 8)
+		f.Int(2, 8)
+		f.Int(3, 8)
+		f.Call()
+		f.Free()
+		if expected := (ThreeLongs{1, 2, 3}); ret != expected {
+			t.Errorf("ReturnThreeLongs returned %+v wanted %+v", ret, expected)
+		}
+	}
+	{
+		type TwoFloats struct{ a, b float32 }
+		sym, err := load.OpenSymbol(lib, "ReturnTwoFloats")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ret TwoFloats
+		f := purego.NewFrame(sym)
+		f.StructResult(unsafe.Pointer(&ret), reflect.TypeOf(ret))
+		f.Float32(1)
+		f.Float32(2)
+		f.Call()
+		f.Free()
+		if expected := (TwoFloats{-1, 2}); ret != expected {
+			t.Errorf("ReturnTwoFloats returned %+v wanted %+v", ret, expected)
+		}
+	}
+}
+
+func TestFrame_tooManyArguments(t *testing.T) {
+	f := purego.NewFrame(0)
+	defer f.Free()
+	defer func() {
+		if r := recover(); r != "purego: too many arguments" {
+			t.Errorf("adding too many arguments panicked with %v", r)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		f.Int(uintptr(i), 8)
+		f.Float64(float64(i))
+	}
+}
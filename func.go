@@ -6,6 +6,7 @@
 package purego
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"reflect"
@@ -32,16 +33,30 @@ func RegisterLibFunc(fptr any, handle uintptr, name string) {
 	RegisterFunc(fptr, sym)
 }
 
+// TryRegisterLibFunc is like RegisterLibFunc but returns an error instead of panicking
+// if the symbol can't be found or the type of fptr isn't supported.
+func TryRegisterLibFunc(fptr any, handle uintptr, name string) error {
+	sym, err := loadSymbol(handle, name)
+	if err != nil {
+		return err
+	}
+	return TryRegisterFunc(fptr, sym)
+}
+
 // RegisterFunc takes a pointer to a Go function representing the calling convention of the C function.
 // fptr will be set to a function that when called will call the C function given by cfn with the
 // parameters passed in the correct registers and stack.
 //
-// A panic is produced if the type is not a function pointer or if the function returns more than 1 value.
+// A panic is produced if the type is not a function pointer or if the function returns more than 1 value
+// other than errno.
+// Use TryRegisterFunc to get an error instead.
+// The arguments can take up to 42 machine words in registers and on the stack combined.
 //
 // These conversions describe how a Go type in the fptr will be used to call
 // the C function. It is important to note that there is no way to verify that fptr
 // matches the C function. This also holds true for struct types where the padding
-// needs to be ensured to match that of C; RegisterFunc does not verify this.
+// needs to be ensured to match that of C; RegisterFunc only verifies this for structs
+// with purego tags. See ValidateLayout.
 //
 // # Type Conversions (Go <=> C)
 //
@@ -60,7 +75,9 @@ func RegisterLibFunc(fptr any, handle uintptr, name string) {
 //	int64 <=> int64_t
 //	float32 <=> float
 //	float64 <=> double
-//	struct <=> struct (WIP - darwin only)
+//	complex64 <=> float _Complex (darwin amd64/arm64, linux amd64/arm64/loong64)
+//	complex128 <=> double _Complex (darwin amd64/arm64, linux amd64/arm64/loong64)
+//	struct <=> struct (darwin amd64/arm64, linux amd64/arm64/loong64)
 //	func <=> C function
 //	unsafe.Pointer, *T <=> void*
 //	[]T => void*
@@ -70,6 +87,33 @@ func RegisterLibFunc(fptr any, handle uintptr, name string) {
 // This means that using arg ...any is like a cast to the function with the arguments inside arg.
 // This is not the same as C variadic.
 //
+// # C Variadic Functions
+//
+// To call a C variadic function add a Variadic parameter where the ... is in the C prototype.
+// The parameters after it, including the elements of a last ...any parameter, are passed as C variadic arguments.
+// This follows the calling convention of the platform and applies the default argument promotions so float32
+// is passed as double. C variadic functions are supported on amd64, arm64 and loong64 except on Windows.
+//
+//	// int snprintf(char *str, size_t size, const char *format, ...);
+//	var snprintf func(str []byte, size uintptr, format string, _ purego.Variadic, args ...any) int32
+//	snprintf(buf, uintptr(len(buf)), "%d %.1f", purego.Variadic{}, 42, 1.5)
+//
+//	// int open(const char *path, int flags, ...);
+//	var open func(path string, flags int32, _ purego.Variadic, mode uint32) int32
+//
+// # Errno
+//
+// The function may have an additional last result of type error or syscall.Errno which receives the value of errno
+// right after the C function returns. errno is set to zero before the call and read on the same thread
+// the C function was called on. The error is nil if errno is zero. A function with a single
+// syscall.Errno result is treated as returning that value from C so use error in that case.
+// On Windows amd64 and 386 this holds the error returned by syscall.SyscallN instead.
+//
+//	var closeFn func(fd int32) (int32, error)
+//	if ret, err := closeFn(-1); ret != 0 {
+//		// err is syscall.EBADF
+//	}
+//
 // # Memory
 //
 // In general it is not possible for purego to guarantee the lifetimes of objects returned or received from
@@ -94,6 +138,15 @@ func RegisterLibFunc(fptr any, handle uintptr, name string) {
 // Purego can handle the most common structs that have fields of builtin types like int8, uint16, float32, etc. However,
 // it does not support aligning fields properly. It is therefore the responsibility of the caller to ensure
 // that all padding is added to the Go struct to match the C one. See `BoolStructFn` in struct_test.go for an example.
+// ValidateLayout checks a struct against the C layout rules and the offsets and sizes of the C struct.
+// If the fields of a struct argument or result have `purego:"offset=N,size=N"` tags RegisterFunc checks them
+// the same way and rejects the function if they don't match.
+//
+// # Performance
+//
+// The placement of arguments is computed once per function type when it is registered if every argument is an
+// integer, bool, float, pointer, slice or string and the result isn't a struct. Calling such a function doesn't
+// allocate for its arguments, except to copy strings that are not null-terminated.
 //
 // # Example
 //
@@ -113,278 +166,309 @@ func RegisterLibFunc(fptr any, handle uintptr, name string) {
 //
 // [Cgo rules]: https://pkg.go.dev/cmd/cgo#hdr-Go_references_to_C
 func RegisterFunc(fptr any, cfn uintptr) {
-	fn := reflect.ValueOf(fptr).Elem()
+	if err := TryRegisterFunc(fptr, cfn); err != nil {
+		panic(err)
+	}
+}
+
+// TryRegisterFunc is like RegisterFunc but returns an error instead of panicking if fptr
+// isn't a pointer to a function whose signature is supported by CheckSignature or if cfn is nil.
+// fptr is left unchanged when an error is returned.
+func TryRegisterFunc(fptr any, cfn uintptr) error {
+	ptr := reflect.ValueOf(fptr)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Func {
+		return errors.New("purego: fptr must be a function pointer")
+	}
+	fn := ptr.Elem()
 	ty := fn.Type()
+	if err := CheckSignature(ty); err != nil {
+		return err
+	}
+	if cfn == 0 {
+		return errors.New("purego: cfn is nil")
+	}
+	registerFunc(fn, ty, cfn)
+	return nil
+}
+
+// SignatureError describes why a function type can't be used with RegisterFunc on the current platform.
+type SignatureError struct {
+	Func   reflect.Type // the function type
+	Index  int          // the index of the offending parameter or -1 for the result
+	Kind   reflect.Kind // the kind of the offending parameter or result
+	Reason string       // why it is rejected
+}
+
+func (e *SignatureError) Error() string {
+	what := "result"
+	if e.Index >= 0 {
+		what = "parameter " + strconv.Itoa(e.Index)
+	}
+	return fmt.Sprintf("purego: %s (%s) of %s: %s on %s/%s", what, e.Kind, e.Func, e.Reason, runtime.GOOS, runtime.GOARCH)
+}
+
+// CheckSignature reports whether a function of type ty can be registered with RegisterFunc.
+// It returns a *SignatureError naming the first parameter or result that isn't supported
+// on the current GOOS and GOARCH.
+func CheckSignature(ty reflect.Type) error {
 	if ty.Kind() != reflect.Func {
-		panic("purego: fptr must be a function pointer")
+		return fmt.Errorf("purego: %s is not a function type", ty)
 	}
-	if ty.NumOut() > 1 {
-		panic("purego: function can only return zero or one values")
+	sigErr := func(i int, kind reflect.Kind, reason string) error {
+		return &SignatureError{Func: ty, Index: i, Kind: kind, Reason: reason}
 	}
-	if cfn == 0 {
-		panic("purego: cfn is nil")
+	if ty.NumOut() > 2 || ty.NumOut() == 2 && !hasErrnoResult(ty) {
+		return sigErr(-1, ty.Out(ty.NumOut()-1).Kind(), "functions can only return zero or one values and optionally errno")
 	}
-	if ty.NumOut() == 1 && (ty.Out(0).Kind() == reflect.Float32 || ty.Out(0).Kind() == reflect.Float64) &&
+	outType := returnType(ty)
+	if outType != nil && (outType.Kind() == reflect.Float32 || outType.Kind() == reflect.Float64) &&
 		runtime.GOARCH != "arm64" && runtime.GOARCH != "amd64" && runtime.GOARCH != "loong64" {
-		panic("purego: float returns are not supported")
-	}
-	{
-		// this code checks how many registers and stack this function will use
-		// to avoid crashing with too many arguments
-		var ints int
-		var floats int
-		var stack int
-		for i := 0; i < ty.NumIn(); i++ {
-			arg := ty.In(i)
-			switch arg.Kind() {
-			case reflect.Func:
-				// This only does preliminary testing to ensure the CDecl argument
-				// is the first argument. Full testing is done when the callback is actually
-				// created in NewCallback.
-				for j := 0; j < arg.NumIn(); j++ {
-					in := arg.In(j)
-					if !in.AssignableTo(reflect.TypeOf(CDecl{})) {
-						continue
-					}
-					if j != 0 {
-						panic("purego: CDecl must be the first argument")
-					}
-				}
-			case reflect.String, reflect.Uintptr, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
-				reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Ptr, reflect.UnsafePointer,
-				reflect.Slice, reflect.Bool:
-				if ints < numOfIntegerRegisters() {
-					ints++
-				} else {
-					stack++
-				}
-			case reflect.Float32, reflect.Float64:
-				const is32bit = unsafe.Sizeof(uintptr(0)) == 4
-				if is32bit {
-					panic("purego: floats only supported on 64bit platforms")
-				}
-				if floats < numOfFloatRegisters {
-					floats++
-				} else {
-					stack++
-				}
-			case reflect.Struct:
-				if runtime.GOOS != "darwin" || (runtime.GOARCH != "amd64" && runtime.GOARCH != "arm64") {
-					panic("purego: struct arguments are only supported on darwin amd64 & arm64")
-				}
-				if arg.Size() == 0 {
+		return sigErr(-1, outType.Kind(), "float returns are not supported")
+	}
+	// this code checks how many registers and stack this function will use
+	// to avoid crashing with too many arguments
+	var ints int
+	var floats int
+	var stack int
+	var isVariadic bool
+	for i := 0; i < ty.NumIn(); i++ {
+		arg := ty.In(i)
+		if arg == variadicType {
+			switch {
+			case isVariadic:
+				return sigErr(i, arg.Kind(), "Variadic can only be used once")
+			case runtime.GOOS == "windows" || runtime.GOARCH != "amd64" && runtime.GOARCH != "arm64" && runtime.GOARCH != "loong64":
+				return sigErr(i, arg.Kind(), "C variadic functions are not supported")
+			}
+			isVariadic = true
+			continue
+		}
+		if k := arg.Kind(); isVariadic && (k == reflect.Struct || k == reflect.Complex64 || k == reflect.Complex128) {
+			return sigErr(i, arg.Kind(), k.String()+" arguments after Variadic are not supported")
+		}
+		switch arg.Kind() {
+		case reflect.Func:
+			// This only does preliminary testing to ensure the CDecl argument
+			// is the first argument. Full testing is done when the callback is actually
+			// created in NewCallback.
+			for j := 0; j < arg.NumIn(); j++ {
+				in := arg.In(j)
+				if !in.AssignableTo(reflect.TypeOf(CDecl{})) {
 					continue
 				}
-				addInt := func(u uintptr) {
-					ints++
-				}
-				addFloat := func(u uintptr) {
-					floats++
-				}
-				addStack := func(u uintptr) {
-					stack++
+				if j != 0 {
+					return sigErr(i, arg.Kind(), "CDecl must be the first argument of the callback")
 				}
-				_ = addStruct(reflect.New(arg).Elem(), &ints, &floats, &stack, addInt, addFloat, addStack, nil)
-			default:
-				panic("purego: unsupported kind " + arg.Kind().String())
 			}
-		}
-		if ty.NumOut() == 1 && ty.Out(0).Kind() == reflect.Struct {
-			if runtime.GOOS != "darwin" {
-				panic("purego: struct return values only supported on darwin arm64 & amd64")
+		case reflect.String, reflect.Uintptr, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Ptr, reflect.UnsafePointer,
+			reflect.Slice, reflect.Bool:
+			if ints < numOfIntegerRegisters() {
+				ints++
+			} else {
+				stack++
 			}
-			outType := ty.Out(0)
-			checkStructFieldsSupported(outType)
-			if runtime.GOARCH == "amd64" && outType.Size() > maxRegAllocStructSize {
-				// on amd64 if struct is bigger than 16 bytes allocate the return struct
-				// and pass it in as a hidden first argument.
+		case reflect.Float32, reflect.Float64:
+			const is32bit = unsafe.Sizeof(uintptr(0)) == 4
+			if is32bit {
+				return sigErr(i, arg.Kind(), "floats are only supported on 64bit platforms")
+			}
+			if floats < numOfFloatRegisters {
+				floats++
+			} else {
+				stack++
+			}
+		case reflect.Struct, reflect.Complex64, reflect.Complex128:
+			if !isStructSupported() {
+				return sigErr(i, arg.Kind(), arg.Kind().String()+" arguments are only supported on darwin, and linux amd64, arm64 & loong64")
+			}
+			if arg.Size() == 0 {
+				continue
+			}
+			if arg.Kind() != reflect.Struct {
+				// complex numbers are passed like a struct of the real and imaginary parts
+				arg = complexStruct(arg)
+			} else if err := checkStructFieldsSupported(arg); err != nil {
+				return sigErr(i, arg.Kind(), err.Error())
+			} else if reason := checkLayoutTags(arg); reason != "" {
+				return sigErr(i, arg.Kind(), reason)
+			}
+			addInt := func(u uintptr) {
 				ints++
 			}
+			addFloat := func(u uintptr) {
+				floats++
+			}
+			addStack := func(u uintptr) {
+				stack++
+			}
+			_ = addStruct(reflect.New(arg).Elem(), &ints, &floats, &stack, addInt, addFloat, addStack, nil)
+		default:
+			return sigErr(i, arg.Kind(), "unsupported kind")
 		}
-		sizeOfStack := maxArgs - numOfIntegerRegisters()
-		if stack > sizeOfStack {
-			panic("purego: too many arguments")
+		if stack > maxArgs-numOfIntegerRegisters() {
+			return sigErr(i, arg.Kind(), "too many arguments")
 		}
 	}
-	v := reflect.MakeFunc(ty, func(args []reflect.Value) (results []reflect.Value) {
-		var sysargs [maxArgs]uintptr
-		var floats [numOfFloatRegisters]uintptr
-		var numInts int
-		var numFloats int
-		var numStack int
-		var addStack, addInt, addFloat func(x uintptr)
-		if runtime.GOARCH == "arm64" || runtime.GOOS != "windows" {
-			// Windows arm64 uses the same calling convention as macOS and Linux
-			addStack = func(x uintptr) {
-				sysargs[numOfIntegerRegisters()+numStack] = x
-				numStack++
+	if outType != nil {
+		switch outType.Kind() {
+		case reflect.Struct:
+			if !isStructSupported() {
+				return sigErr(-1, outType.Kind(), "struct return values are only supported on darwin, and linux amd64, arm64 & loong64")
 			}
-			addInt = func(x uintptr) {
-				if numInts >= numOfIntegerRegisters() {
-					addStack(x)
-				} else {
-					sysargs[numInts] = x
-					numInts++
-				}
+			if err := checkStructFieldsSupported(outType); err != nil {
+				return sigErr(-1, outType.Kind(), err.Error())
 			}
-			addFloat = func(x uintptr) {
-				if numFloats < len(floats) {
-					floats[numFloats] = x
-					numFloats++
-				} else {
-					addStack(x)
-				}
+			if reason := checkLayoutTags(outType); reason != "" {
+				return sigErr(-1, outType.Kind(), reason)
 			}
-		} else {
-			// On Windows amd64 the arguments are passed in the numbered registered.
-			// So the first int is in the first integer register and the first float
-			// is in the second floating register if there is already a first int.
-			// This is in contrast to how macOS and Linux pass arguments which
-			// tries to use as many registers as possible in the calling convention.
-			addStack = func(x uintptr) {
-				sysargs[numStack] = x
-				numStack++
+			if runtime.GOARCH == "amd64" && outType.Size() > maxRegAllocStructSize {
+				// on amd64 if struct is bigger than 16 bytes allocate the return struct
+				// and pass it in as a hidden first argument.
+				ints++
 			}
-			addInt = addStack
-			addFloat = addStack
+		case reflect.Complex64, reflect.Complex128:
+			if !isStructSupported() {
+				return sigErr(-1, outType.Kind(), "complex return values are only supported on darwin, and linux amd64, arm64 & loong64")
+			}
+		case reflect.Uintptr, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Bool,
+			reflect.UnsafePointer, reflect.Ptr, reflect.Func, reflect.String, reflect.Float32, reflect.Float64:
+		default:
+			return sigErr(-1, outType.Kind(), "unsupported kind")
 		}
+	}
+	return nil
+}
 
-		var keepAlive []any
-		defer func() {
-			runtime.KeepAlive(keepAlive)
-			runtime.KeepAlive(args)
-		}()
+func registerFunc(fn reflect.Value, ty reflect.Type, cfn uintptr) {
+	fn.Set(reflect.MakeFunc(ty, callFunc(ty, cfn)))
+}
 
-		var arm64_r8 uintptr
-		if ty.NumOut() == 1 && ty.Out(0).Kind() == reflect.Struct {
-			outType := ty.Out(0)
-			if (runtime.GOARCH == "amd64" || runtime.GOARCH == "loong64") && outType.Size() > maxRegAllocStructSize {
-				val := reflect.New(outType)
-				keepAlive = append(keepAlive, val)
-				addInt(val.Pointer())
-			} else if runtime.GOARCH == "arm64" && outType.Size() > maxRegAllocStructSize {
-				isAllFloats, numFields := isAllSameFloat(outType)
-				if !isAllFloats || numFields > 4 {
-					val := reflect.New(outType)
-					keepAlive = append(keepAlive, val)
-					arm64_r8 = val.Pointer()
-				}
-			}
+// callFunc returns the implementation of a function of type ty that calls cfn.
+func callFunc(ty reflect.Type, cfn uintptr) func(args []reflect.Value) []reflect.Value {
+	if plan := getCallPlan(ty); plan != nil {
+		return func(args []reflect.Value) []reflect.Value {
+			return plan.call(cfn, args)
+		}
+	}
+	outType := returnType(ty)
+	var errnoFn uintptr
+	if hasErrnoResult(ty) {
+		errnoFn = errnoLocation
+	}
+	return func(args []reflect.Value) (results []reflect.Value) {
+		f := NewFrame(cfn)
+		defer f.Free()
+		f.args.errnoFn = errnoFn
+		defer runtime.KeepAlive(args)
+
+		if outType != nil && outType.Kind() == reflect.Struct && outType.Size() > maxRegAllocStructSize {
+			val := reflect.New(outType)
+			f.keepAlive = append(f.keepAlive, val)
+			f.StructResult(val.UnsafePointer(), outType)
 		}
 		for i, v := range args {
+			if v.Type() == variadicType {
+				f.Variadic()
+				continue
+			}
 			if variadic, ok := xreflect.TypeAssert[[]any](args[i]); ok {
 				if i != len(args)-1 {
 					panic("purego: can only expand last parameter")
 				}
 				for _, x := range variadic {
-					keepAlive = addValue(reflect.ValueOf(x), keepAlive, addInt, addFloat, addStack, &numInts, &numFloats, &numStack)
+					xv := reflect.ValueOf(x)
+					if f.variadic {
+						xv = promoteVariadic(xv)
+					}
+					f.addValue(xv)
 				}
 				continue
 			}
-			if runtime.GOARCH == "arm64" && runtime.GOOS == "darwin" &&
-				(numInts >= numOfIntegerRegisters() || numFloats >= numOfFloatRegisters) && v.Kind() != reflect.Struct { // hit the stack
-				fields := make([]reflect.StructField, len(args[i:]))
-
-				for j, val := range args[i:] {
-					if val.Kind() == reflect.String {
-						ptr := strings.CString(val.String())
-						keepAlive = append(keepAlive, ptr)
-						val = reflect.ValueOf(ptr)
-						args[i+j] = val
-					}
-					fields[j] = reflect.StructField{
-						Name: "X" + strconv.Itoa(j),
-						Type: val.Type(),
-					}
-				}
-				structType := reflect.StructOf(fields)
-				structInstance := reflect.New(structType).Elem()
-				for j, val := range args[i:] {
-					structInstance.Field(j).Set(val)
-				}
-				placeRegisters(structInstance, addFloat, addInt)
-				break
+			if f.variadic {
+				v = promoteVariadic(v)
 			}
-			keepAlive = addValue(v, keepAlive, addInt, addFloat, addStack, &numInts, &numFloats, &numStack)
+			f.addValue(v)
 		}
+		f.call()
+		return makeResults(ty, outType, &f.args, args)
+	}
+}
 
-		syscall := thePool.Get().(*syscall15Args)
-		defer thePool.Put(syscall)
+// makeResults creates the results of a function of type ty from the registers saved in syscall.
+// The args slice is reused to hold them when possible.
+func makeResults(ty, outType reflect.Type, syscall *syscall15Args, args []reflect.Value) []reflect.Value {
+	n := ty.NumOut()
+	if n == 0 {
+		return nil
+	}
+	if len(args) < n {
+		args = make([]reflect.Value, n)
+	}
+	args = args[:n]
+	if outType != nil {
+		args[0] = returnValue(outType, syscall)
+	}
+	if hasErrnoResult(ty) {
+		args[n-1] = errnoValue(ty.Out(n-1), syscall.err)
+	}
+	return args
+}
 
-		if runtime.GOARCH == "loong64" {
-			*syscall = syscall15Args{
-				cfn,
-				sysargs[0], sysargs[1], sysargs[2], sysargs[3], sysargs[4], sysargs[5],
-				sysargs[6], sysargs[7], sysargs[8], sysargs[9], sysargs[10], sysargs[11],
-				sysargs[12], sysargs[13], sysargs[14],
-				floats[0], floats[1], floats[2], floats[3], floats[4], floats[5], floats[6], floats[7],
-				0,
-			}
-			runtime_cgocall(syscall15XABI0, unsafe.Pointer(syscall))
-		} else if runtime.GOARCH == "arm64" || runtime.GOOS != "windows" {
-			// Use the normal arm64 calling convention even on Windows
-			*syscall = syscall15Args{
-				cfn,
-				sysargs[0], sysargs[1], sysargs[2], sysargs[3], sysargs[4], sysargs[5],
-				sysargs[6], sysargs[7], sysargs[8], sysargs[9], sysargs[10], sysargs[11],
-				sysargs[12], sysargs[13], sysargs[14],
-				floats[0], floats[1], floats[2], floats[3], floats[4], floats[5], floats[6], floats[7],
-				arm64_r8,
-			}
-			runtime_cgocall(syscall15XABI0, unsafe.Pointer(syscall))
-		} else {
-			*syscall = syscall15Args{}
-			// This is a fallback for Windows amd64, 386, and arm. Note this may not support floats
-			syscall.a1, syscall.a2, _ = syscall_syscall15X(cfn, sysargs[0], sysargs[1], sysargs[2], sysargs[3], sysargs[4],
-				sysargs[5], sysargs[6], sysargs[7], sysargs[8], sysargs[9], sysargs[10], sysargs[11],
-				sysargs[12], sysargs[13], sysargs[14])
-			syscall.f1 = syscall.a2 // on amd64 a2 stores the float return. On 32bit platforms floats aren't support
-		}
-		if ty.NumOut() == 0 {
-			return nil
-		}
-		outType := ty.Out(0)
-		v := reflect.New(outType).Elem()
-		switch outType.Kind() {
-		case reflect.Uintptr, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			v.SetUint(uint64(syscall.a1))
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			v.SetInt(int64(syscall.a1))
-		case reflect.Bool:
-			v.SetBool(byte(syscall.a1) != 0)
-		case reflect.UnsafePointer:
-			// We take the address and then dereference it to trick go vet from creating a possible miss-use of unsafe.Pointer
-			v.SetPointer(*(*unsafe.Pointer)(unsafe.Pointer(&syscall.a1)))
-		case reflect.Ptr:
-			v = reflect.NewAt(outType, unsafe.Pointer(&syscall.a1)).Elem()
-		case reflect.Func:
-			// wrap this C function in a nicely typed Go function
-			v = reflect.New(outType)
-			RegisterFunc(v.Interface(), syscall.a1)
-		case reflect.String:
-			v.SetString(strings.GoString(syscall.a1))
-		case reflect.Float32:
-			// NOTE: syscall.r2 is only the floating return value on 64bit platforms.
-			// On 32bit platforms syscall.r2 is the upper part of a 64bit return.
-			v.SetFloat(float64(math.Float32frombits(uint32(syscall.f1))))
-		case reflect.Float64:
-			// NOTE: syscall.r2 is only the floating return value on 64bit platforms.
-			// On 32bit platforms syscall.r2 is the upper part of a 64bit return.
-			v.SetFloat(math.Float64frombits(uint64(syscall.f1)))
-		case reflect.Struct:
-			v = getStruct(outType, *syscall)
-		default:
-			panic("purego: unsupported return kind: " + outType.Kind().String())
-		}
-		if len(args) > 0 {
-			// reuse args slice instead of allocating one when possible
-			args[0] = v
-			return args[:1]
-		} else {
-			return []reflect.Value{v}
-		}
-	})
-	fn.Set(v)
+// promoteVariadic applies the C default argument promotions to a variadic argument.
+// Only float needs to be converted to double as smaller integers are already
+// extended to the full register.
+func promoteVariadic(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Float32 {
+		return reflect.ValueOf(v.Float())
+	}
+	return v
+}
+
+// returnValue converts the result registers saved in syscall into a value of outType.
+// The returned value never refers to the memory of syscall so that it can be reused.
+func returnValue(outType reflect.Type, syscall *syscall15Args) reflect.Value {
+	switch outType.Kind() {
+	case reflect.UnsafePointer:
+		// We take the address and then dereference it to trick go vet from creating a possible miss-use of unsafe.Pointer
+		// Convert handles named types without allocating.
+		return reflect.ValueOf(*(*unsafe.Pointer)(unsafe.Pointer(&syscall.a1))).Convert(outType)
+	case reflect.Ptr:
+		return reflect.NewAt(outType.Elem(), *(*unsafe.Pointer)(unsafe.Pointer(&syscall.a1))).Convert(outType)
+	case reflect.Func:
+		// wrap this C function in a nicely typed Go function
+		v := reflect.New(outType)
+		RegisterFunc(v.Interface(), syscall.a1)
+		return v.Elem()
+	case reflect.Struct:
+		return getStruct(outType, *syscall)
+	case reflect.Complex64, reflect.Complex128:
+		v := getStruct(complexStruct(outType), *syscall)
+		return reflect.ValueOf(complex(v.Field(0).Float(), v.Field(1).Float())).Convert(outType)
+	}
+	v := reflect.New(outType).Elem()
+	switch outType.Kind() {
+	case reflect.Uintptr, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(syscall.a1))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(syscall.a1))
+	case reflect.Bool:
+		v.SetBool(byte(syscall.a1) != 0)
+	case reflect.String:
+		v.SetString(strings.GoString(syscall.a1))
+	case reflect.Float32:
+		// NOTE: syscall.r2 is only the floating return value on 64bit platforms.
+		// On 32bit platforms syscall.r2 is the upper part of a 64bit return.
+		v.SetFloat(float64(math.Float32frombits(uint32(syscall.f1))))
+	case reflect.Float64:
+		// NOTE: syscall.r2 is only the floating return value on 64bit platforms.
+		// On 32bit platforms syscall.r2 is the upper part of a 64bit return.
+		v.SetFloat(math.Float64frombits(uint64(syscall.f1)))
+	default:
+		panic("purego: unsupported return kind: " + outType.Kind().String())
+	}
+	return v
 }
 
 func addValue(v reflect.Value, keepAlive []any, addInt func(x uintptr), addFloat func(x uintptr), addStack func(x uintptr), numInts *int, numFloats *int, numStack *int) []any {
@@ -414,12 +498,42 @@ func addValue(v reflect.Value, keepAlive []any, addInt func(x uintptr), addFloat
 		addFloat(uintptr(math.Float64bits(v.Float())))
 	case reflect.Struct:
 		keepAlive = addStruct(v, numInts, numFloats, numStack, addInt, addFloat, addStack, keepAlive)
+	case reflect.Complex64, reflect.Complex128:
+		keepAlive = addStruct(complexToStruct(v), numInts, numFloats, numStack, addInt, addFloat, addStack, keepAlive)
 	default:
 		panic("purego: unsupported kind: " + v.Kind().String())
 	}
 	return keepAlive
 }
 
+// complex64Struct and complex128Struct have the same layout as complex64 and complex128.
+// C passes float _Complex and double _Complex like a struct of the real and imaginary parts.
+var (
+	complex64Struct  = reflect.TypeOf(struct{ Real, Imag float32 }{})
+	complex128Struct = reflect.TypeOf(struct{ Real, Imag float64 }{})
+)
+
+func isComplex(ty reflect.Type) bool {
+	return ty.Kind() == reflect.Complex64 || ty.Kind() == reflect.Complex128
+}
+
+// complexStruct returns the struct type that is passed in place of the complex type ty.
+func complexStruct(ty reflect.Type) reflect.Type {
+	if ty.Kind() == reflect.Complex64 {
+		return complex64Struct
+	}
+	return complex128Struct
+}
+
+// complexToStruct converts the complex value v into a value of type complexStruct(v.Type()).
+func complexToStruct(v reflect.Value) reflect.Value {
+	c := v.Complex()
+	if v.Kind() == reflect.Complex64 {
+		return reflect.ValueOf(struct{ Real, Imag float32 }{float32(real(c)), float32(imag(c))})
+	}
+	return reflect.ValueOf(struct{ Real, Imag float64 }{real(c), imag(c)})
+}
+
 // maxRegAllocStructSize is the biggest a struct can be while still fitting in registers.
 // if it is bigger than this than enough space must be allocated on the heap and then passed into
 // the function as the first parameter on amd64 or in R8 on arm64.
@@ -453,23 +567,68 @@ func isAllSameFloat(ty reflect.Type) (allFloats bool, numFields int) {
 	return allFloats, numFields
 }
 
-func checkStructFieldsSupported(ty reflect.Type) {
+// addFloatFields calls addFloat with each field of v which isAllSameFloat reports to be all floats.
+func addFloatFields(v reflect.Value, addFloat func(uintptr)) {
+	for i := 0; i < v.NumField(); i++ {
+		switch f := v.Field(i); f.Kind() {
+		case reflect.Struct:
+			addFloatFields(f, addFloat)
+		case reflect.Float32:
+			addFloat(uintptr(math.Float32bits(float32(f.Float()))))
+		default:
+			addFloat(uintptr(math.Float64bits(f.Float())))
+		}
+	}
+}
+
+// addStructWords calls addInt with each word of the memory of the struct v. The last word
+// is padded with zeros if the size of v isn't a multiple of 8.
+func addStructWords(v reflect.Value, addInt func(uintptr)) {
+	if v.Type().Size() == 0 {
+		return
+	}
+	// copy v into whole words so that the last one isn't read past the end of v
+	words := make([]uintptr, roundUpTo8(v.Type().Size())/8)
+	reflect.NewAt(v.Type(), unsafe.Pointer(&words[0])).Elem().Set(v)
+	for _, w := range words {
+		addInt(w)
+	}
+}
+
+func checkStructFieldsSupported(ty reflect.Type) error {
 	for i := 0; i < ty.NumField(); i++ {
 		f := ty.Field(i).Type
 		if f.Kind() == reflect.Array {
 			f = f.Elem()
-		} else if f.Kind() == reflect.Struct {
-			checkStructFieldsSupported(f)
+		}
+		if f.Kind() == reflect.Struct {
+			if err := checkStructFieldsSupported(f); err != nil {
+				return err
+			}
 			continue
 		}
 		switch f.Kind() {
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
-			reflect.Uintptr, reflect.Ptr, reflect.UnsafePointer, reflect.Float64, reflect.Float32:
+			reflect.Uintptr, reflect.Ptr, reflect.UnsafePointer, reflect.Float64, reflect.Float32,
+			reflect.Bool:
 		default:
-			panic(fmt.Sprintf("purego: struct field type %s is not supported", f))
+			return fmt.Errorf("struct field type %s is not supported", f)
 		}
 	}
+	return nil
+}
+
+// isStructSupported reports whether struct arguments and return values
+// can be passed by value on the current platform.
+func isStructSupported() bool {
+	switch runtime.GOOS {
+	case "darwin":
+		return runtime.GOARCH == "amd64" || runtime.GOARCH == "arm64"
+	case "linux":
+		return runtime.GOARCH == "amd64" || runtime.GOARCH == "arm64" || runtime.GOARCH == "loong64"
+	}
+	return false
 }
 
 func roundUpTo8(val uintptr) uintptr {
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
@@ -135,6 +136,162 @@ func TestRegisterLibFunc_Bool(t *testing.T) {
 	}
 }
 
+func TestRegisterFunc_allocs(t *testing.T) {
+	if runtime.GOARCH != "arm64" && runtime.GOARCH != "amd64" && runtime.GOARCH != "loong64" ||
+		runtime.GOOS == "windows" && runtime.GOARCH == "amd64" {
+		t.Skip("Platform doesn't use call plans")
+	}
+	library, err := getSystemLibrary()
+	if err != nil {
+		t.Fatalf("couldn't get system library: %s", err)
+	}
+	libc, err := load.OpenLibrary(library)
+	if err != nil {
+		t.Fatalf("failed to dlopen: %s", err)
+	}
+
+	// reflectAllocs returns the allocations that reflect.MakeFunc itself needs to call a function of type fptr
+	// plus boxing a non-pointer result. Calling through RegisterFunc must not allocate anything on top of that.
+	reflectAllocs := func(fptr any, call func()) float64 {
+		fn := reflect.ValueOf(fptr).Elem()
+		fn.Set(reflect.MakeFunc(fn.Type(), func(args []reflect.Value) []reflect.Value {
+			if fn.Type().NumOut() == 0 {
+				return nil
+			}
+			switch out := fn.Type().Out(0); out.Kind() {
+			case reflect.Ptr, reflect.UnsafePointer:
+				args[0] = reflect.Zero(out)
+			default:
+				args[0] = reflect.New(out).Elem()
+			}
+			return args[:1]
+		}))
+		return testing.AllocsPerRun(100, call)
+	}
+
+	{
+		var strchr func(s *byte, c int32) *byte
+		buf := []byte("hello\x00")
+		call := func() { strchr(&buf[0], 'l') }
+		want := reflectAllocs(&strchr, call)
+		purego.RegisterLibFunc(&strchr, libc, "strchr")
+		if got := testing.AllocsPerRun(100, call); got > want {
+			t.Errorf("strchr: got %v allocs, want at most %v", got, want)
+		}
+		if got := strchr(&buf[0], 'l'); got != &buf[2] {
+			t.Errorf("strchr: got %p, want %p", got, &buf[2])
+		}
+	}
+	{
+		var strlen func(s string) uintptr
+		call := func() { strlen("hello\x00") }
+		want := reflectAllocs(&strlen, call)
+		purego.RegisterLibFunc(&strlen, libc, "strlen")
+		if got := testing.AllocsPerRun(100, call); got > want {
+			t.Errorf("strlen: got %v allocs, want at most %v", got, want)
+		}
+	}
+	{
+		var ldexp func(x float64, exp int32) float64
+		call := func() { ldexp(1.5, 3) }
+		want := reflectAllocs(&ldexp, call)
+		purego.RegisterLibFunc(&ldexp, libc, "ldexp")
+		if got := testing.AllocsPerRun(100, call); got > want {
+			t.Errorf("ldexp: got %v allocs, want at most %v", got, want)
+		}
+		if got := ldexp(1.5, 3); got != 12 {
+			t.Errorf("ldexp: got %v, want %v", got, 12)
+		}
+	}
+	{
+		var memchr func(s unsafe.Pointer, c int32, n uintptr) unsafe.Pointer
+		buf := []byte("hello")
+		call := func() { memchr(unsafe.Pointer(&buf[0]), 'o', uintptr(len(buf))) }
+		want := reflectAllocs(&memchr, call)
+		purego.RegisterLibFunc(&memchr, libc, "memchr")
+		if got := testing.AllocsPerRun(100, call); got > want {
+			t.Errorf("memchr: got %v allocs, want at most %v", got, want)
+		}
+	}
+}
+
+func TestTryRegisterFunc(t *testing.T) {
+	library, err := getSystemLibrary()
+	if err != nil {
+		t.Fatalf("couldn't get system library: %s", err)
+	}
+	libc, err := load.OpenLibrary(library)
+	if err != nil {
+		t.Fatalf("failed to dlopen: %s", err)
+	}
+	{
+		var fn func(int, chan int) int
+		err := purego.TryRegisterLibFunc(&fn, libc, "abs")
+		var sigErr *purego.SignatureError
+		if !errors.As(err, &sigErr) {
+			t.Fatalf("TryRegisterLibFunc: got %v, want a *SignatureError", err)
+		}
+		if sigErr.Index != 1 || sigErr.Kind != reflect.Chan {
+			t.Errorf("TryRegisterLibFunc: got parameter %d (%s), want parameter 1 (chan)", sigErr.Index, sigErr.Kind)
+		}
+		if !strings.Contains(err.Error(), runtime.GOOS+"/"+runtime.GOARCH) {
+			t.Errorf("TryRegisterLibFunc: error %q doesn't mention the platform", err)
+		}
+		if fn != nil {
+			t.Errorf("TryRegisterLibFunc: fn was set despite the error")
+		}
+	}
+	{
+		var fn func() map[int]int
+		err := purego.CheckSignature(reflect.TypeOf(fn))
+		var sigErr *purego.SignatureError
+		if !errors.As(err, &sigErr) || sigErr.Index != -1 || sigErr.Kind != reflect.Map {
+			t.Errorf("CheckSignature: got %v, want an error for the result", err)
+		}
+	}
+	{
+		in := make([]reflect.Type, 43)
+		for i := range in {
+			in[i] = reflect.TypeOf(0)
+		}
+		err := purego.CheckSignature(reflect.FuncOf(in, nil, false))
+		var sigErr *purego.SignatureError
+		if !errors.As(err, &sigErr) || sigErr.Index != 42 {
+			t.Errorf("CheckSignature: got %v, want too many arguments at parameter 42", err)
+		}
+	}
+	{
+		var fn func() (int, int)
+		err := purego.CheckSignature(reflect.TypeOf(fn))
+		var sigErr *purego.SignatureError
+		if !errors.As(err, &sigErr) || sigErr.Index != -1 {
+			t.Errorf("CheckSignature: got %v, want a *SignatureError for multiple results", err)
+		}
+		var three func() (int32, error, error)
+		if err := purego.CheckSignature(reflect.TypeOf(three)); !errors.As(err, &sigErr) || sigErr.Index != -1 {
+			t.Errorf("CheckSignature: got %v, want a *SignatureError for three results", err)
+		}
+		if err := purego.TryRegisterFunc(fn, 1); err == nil {
+			t.Errorf("TryRegisterFunc: got nil error for a non pointer")
+		}
+	}
+	{
+		var fn func(string) int32
+		if err := purego.TryRegisterLibFunc(&fn, libc, "purego_missing_symbol"); err == nil {
+			t.Errorf("TryRegisterLibFunc: got nil error for a missing symbol")
+		}
+		if err := purego.TryRegisterFunc(&fn, 0); err == nil {
+			t.Errorf("TryRegisterFunc: got nil error for a nil cfn")
+		}
+		if err := purego.TryRegisterLibFunc(&fn, libc, "atoi"); err != nil {
+			t.Fatalf("TryRegisterLibFunc: %v", err)
+		}
+		if got := fn("42"); got != 42 {
+			t.Errorf("atoi: got %d, want %d", got, 42)
+		}
+	}
+}
+
 func TestABI(t *testing.T) {
 	if runtime.GOOS == "windows" && runtime.GOARCH == "386" {
 		t.Skip("need a 32bit gcc to run this test") // TODO: find 32bit gcc for test
@@ -197,6 +354,40 @@ func TestABI(t *testing.T) {
 			t.Fatalf("%s: got %q, want %q", cName, res, want)
 		}
 	}
+	{
+		const cName = "stack_20_intptr_t"
+		const expect = 2870
+		var fn func(a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14, a15, a16, a17, a18, a19, a20 int) int
+		purego.RegisterLibFunc(&fn, lib, cName)
+		res := fn(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20)
+		if res != expect {
+			t.Fatalf("%s: got %d, want %d", cName, res, expect)
+		}
+		var fnAny func(args ...any) int
+		purego.RegisterLibFunc(&fnAny, lib, cName)
+		res = fnAny(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20)
+		if res != expect {
+			t.Fatalf("%s with ...any: got %d, want %d", cName, res, expect)
+		}
+		sym, err := load.OpenSymbol(lib, cName)
+		if err != nil {
+			t.Fatalf("%s: %v", cName, err)
+		}
+		r1, _, _ := purego.SyscallN(sym, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20)
+		if r1 != expect {
+			t.Fatalf("SyscallN %s: got %d, want %d", cName, r1, expect)
+		}
+	}
+	if runtime.GOOS != "windows" && (runtime.GOARCH == "amd64" || runtime.GOARCH == "arm64" || runtime.GOARCH == "loong64") {
+		const cName = "stack_10_intptr_t_10_doubles"
+		const expect = 797.5
+		var fn func(a1, a2, a3, a4, a5, a6, a7, a8, a9, a10 int, d1, d2, d3, d4, d5, d6, d7, d8, d9, d10 float64) float64
+		purego.RegisterLibFunc(&fn, lib, cName)
+		res := fn(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 1.5, 2.5, 3.5, 4.5, 5.5, 6.5, 7.5, 8.5, 9.5, 10.5)
+		if res != expect {
+			t.Fatalf("%s: got %f, want %f", cName, res, expect)
+		}
+	}
 }
 
 func buildSharedLib(compilerEnv, libFile string, sources ...string) error {
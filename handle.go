@@ -0,0 +1,79 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package purego
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Handle provides a way to pass values that contain Go pointers
+// (pointers to memory allocated by Go) between Go and C without
+// breaking the pointer passing rules. A Handle is an integer
+// value that can represent any Go value. It is typically passed
+// as the void *userdata argument of C APIs that take callbacks.
+// It works the same way as runtime/cgo.Handle but doesn't need Cgo.
+//
+// A Handle can be a parameter of a function given to NewCallback
+// in which case it arrives as the Handle that was passed to C.
+// A parameter of an interface type receives its Value instead or nil if C passes NULL:
+//
+//	cb := purego.NewCallback(func(userdata any) {
+//		s := userdata.(*State)
+//		...
+//	})
+//	h := purego.NewHandle(state)
+//	defer h.Delete()
+//	register(cb, h)
+//
+// The zero value of a Handle is not valid.
+type Handle uintptr
+
+var (
+	handles   = sync.Map{} // map[uintptr]any
+	handleIdx uintptr      // the last handle created
+)
+
+// NewHandle returns a handle for a given value.
+//
+// The handle is valid until the program calls Delete on it. The handle
+// uses resources, and this package assumes that C code may hold on to
+// the handle, so a program must explicitly call Delete when the handle
+// is no longer needed.
+//
+// The intended use is to pass the returned handle to C code, which
+// passes it back to Go, which calls Value.
+func NewHandle(v any) Handle {
+	h := atomic.AddUintptr(&handleIdx, 1)
+	if h == 0 {
+		panic("purego: ran out of handle space")
+	}
+
+	handles.Store(h, v)
+	return Handle(h)
+}
+
+// Value returns the associated Go value for a valid handle.
+//
+// The method panics if the handle is invalid.
+func (h Handle) Value() any {
+	v, ok := handles.Load(uintptr(h))
+	if !ok {
+		panic("purego: misuse of an invalid Handle")
+	}
+	return v
+}
+
+// Delete invalidates a handle. This method should only be called once
+// the program no longer needs to pass the handle to C and the C code
+// no longer has a copy of the handle value.
+//
+// The method panics if the handle is invalid.
+func (h Handle) Delete() {
+	_, ok := handles.LoadAndDelete(uintptr(h))
+	if !ok {
+		panic("purego: misuse of an invalid Handle")
+	}
+}
@@ -0,0 +1,25 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build !android
+
+package cgo
+
+/*
+#cgo LDFLAGS: -ldl
+
+#define _GNU_SOURCE
+#include <dlfcn.h>
+#include <link.h>
+*/
+import "C"
+
+// the GNU extensions are only declared with _GNU_SOURCE so they are referenced here
+// for dlfcn_gnu_linux.go in package purego.
+var (
+	_ = C.dladdr
+	_ = C.dlinfo
+	_ = C.dlvsym
+	_ = C.dlmopen
+	_ = C.dl_iterate_phdr
+)
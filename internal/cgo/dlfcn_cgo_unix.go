@@ -15,10 +15,14 @@ import "C"
 
 import (
 	"errors"
+	"runtime"
 	"unsafe"
 )
 
 func Dlopen(filename string, flag int) (uintptr, error) {
+	// dlerror is thread-local so it must be read on the thread that failed
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 	cfilename := C.CString(filename)
 	defer C.free(unsafe.Pointer(cfilename))
 	handle := C.dlopen(cfilename, C.int(flag))
@@ -29,6 +33,8 @@ func Dlopen(filename string, flag int) (uintptr, error) {
 }
 
 func Dlsym(handle uintptr, symbol string) (uintptr, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 	csymbol := C.CString(symbol)
 	defer C.free(unsafe.Pointer(csymbol))
 	symbolAddr := C.dlsym(*(*unsafe.Pointer)(unsafe.Pointer(&handle)), csymbol)
@@ -39,6 +45,8 @@ func Dlsym(handle uintptr, symbol string) (uintptr, error) {
 }
 
 func Dlclose(handle uintptr) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 	result := C.dlclose(*(*unsafe.Pointer)(unsafe.Pointer(&handle)))
 	if result != 0 {
 		return errors.New(C.GoString(C.dlerror()))
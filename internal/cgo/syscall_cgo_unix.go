@@ -16,21 +16,38 @@ package cgo
 #include <errno.h>
 #include <assert.h>
 
+// MAX_ARGS must match maxArgs in package purego.
+#define MAX_ARGS 42
+
 typedef struct syscall15Args {
 	uintptr_t fn;
-	uintptr_t a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14, a15;
 	uintptr_t f1, f2, f3, f4, f5, f6, f7, f8;
+	uintptr_t arm64_r8;
+	uintptr_t errnoFn;
 	uintptr_t err;
+	uintptr_t nstack;
+	uintptr_t a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14, a15;
+	uintptr_t a16[MAX_ARGS - 15];
 } syscall15Args;
 
 void syscall15(struct syscall15Args *args) {
 	assert((args->f1|args->f2|args->f3|args->f4|args->f5|args->f6|args->f7|args->f8) == 0);
-	uintptr_t (*func_name)(uintptr_t a1, uintptr_t a2, uintptr_t a3, uintptr_t a4, uintptr_t a5, uintptr_t a6,
-		uintptr_t a7, uintptr_t a8, uintptr_t a9, uintptr_t a10, uintptr_t a11, uintptr_t a12,
-		uintptr_t a13, uintptr_t a14, uintptr_t a15);
+	uintptr_t (*func_name)(
+		uintptr_t a1, uintptr_t a2, uintptr_t a3, uintptr_t a4, uintptr_t a5, uintptr_t a6, uintptr_t a7, uintptr_t a8,
+		uintptr_t a9, uintptr_t a10, uintptr_t a11, uintptr_t a12, uintptr_t a13, uintptr_t a14, uintptr_t a15,
+		uintptr_t a16, uintptr_t a17, uintptr_t a18, uintptr_t a19, uintptr_t a20, uintptr_t a21, uintptr_t a22,
+		uintptr_t a23, uintptr_t a24, uintptr_t a25, uintptr_t a26, uintptr_t a27, uintptr_t a28, uintptr_t a29,
+		uintptr_t a30, uintptr_t a31, uintptr_t a32, uintptr_t a33, uintptr_t a34, uintptr_t a35, uintptr_t a36,
+		uintptr_t a37, uintptr_t a38, uintptr_t a39, uintptr_t a40, uintptr_t a41, uintptr_t a42);
 	*(void**)(&func_name) = (void*)(args->fn);
-	uintptr_t r1 =  func_name(args->a1,args->a2,args->a3,args->a4,args->a5,args->a6,args->a7,args->a8,args->a9,
-		args->a10,args->a11,args->a12,args->a13,args->a14,args->a15);
+	errno = 0;
+	uintptr_t r1 = func_name(
+		args->a1, args->a2, args->a3, args->a4, args->a5, args->a6, args->a7, args->a8, args->a9, args->a10, args->a11,
+		args->a12, args->a13, args->a14, args->a15, args->a16[0], args->a16[1], args->a16[2], args->a16[3],
+		args->a16[4], args->a16[5], args->a16[6], args->a16[7], args->a16[8], args->a16[9], args->a16[10],
+		args->a16[11], args->a16[12], args->a16[13], args->a16[14], args->a16[15], args->a16[16], args->a16[17],
+		args->a16[18], args->a16[19], args->a16[20], args->a16[21], args->a16[22], args->a16[23], args->a16[24],
+		args->a16[25], args->a16[26]);
 	args->a1 = r1;
 	args->err = errno;
 }
@@ -42,14 +59,16 @@ import "unsafe"
 // assign purego.syscall15XABI0 to the C version of this function.
 var Syscall15XABI0 = unsafe.Pointer(C.syscall15)
 
+// SyscallN calls fn with args which must not be more than MAX_ARGS.
+//
 //go:nosplit
-func Syscall15X(fn, a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14, a15 uintptr) (r1, r2, err uintptr) {
-	args := C.syscall15Args{
-		C.uintptr_t(fn), C.uintptr_t(a1), C.uintptr_t(a2), C.uintptr_t(a3),
-		C.uintptr_t(a4), C.uintptr_t(a5), C.uintptr_t(a6),
-		C.uintptr_t(a7), C.uintptr_t(a8), C.uintptr_t(a9), C.uintptr_t(a10), C.uintptr_t(a11), C.uintptr_t(a12),
-		C.uintptr_t(a13), C.uintptr_t(a14), C.uintptr_t(a15), 0, 0, 0, 0, 0, 0, 0, 0, 0,
+func SyscallN(fn uintptr, args []uintptr) (r1, r2, err uintptr) {
+	var sysargs C.syscall15Args
+	sysargs.fn = C.uintptr_t(fn)
+	a := (*[C.MAX_ARGS]C.uintptr_t)(unsafe.Pointer(&sysargs.a1))
+	for i, x := range args {
+		a[i] = C.uintptr_t(x)
 	}
-	C.syscall15(&args)
-	return uintptr(args.a1), 0, uintptr(args.err)
+	C.syscall15(&sysargs)
+	return uintptr(sysargs.a1), 0, uintptr(sysargs.err)
 }
@@ -0,0 +1,242 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build darwin || freebsd || linux || netbsd || windows
+
+package purego
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// Layout describes the layout of a C struct as reported by sizeof, _Alignof and offsetof.
+// Zero values are not checked.
+type Layout struct {
+	Size   uintptr
+	Align  uintptr
+	Fields []FieldLayout
+}
+
+// FieldLayout describes a single field of a C struct. Name is the name of the Go field.
+type FieldLayout struct {
+	Name   string
+	Offset uintptr
+	Size   uintptr
+}
+
+// LayoutError describes the first field of a Go struct that doesn't match the C layout.
+type LayoutError struct {
+	Struct reflect.Type // the struct type
+	Field  string       // the name of the field or empty if it is about the whole struct
+	Reason string       // how it differs
+}
+
+func (e *LayoutError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("purego: %s: %s on %s/%s", e.Struct, e.Reason, runtime.GOOS, runtime.GOARCH)
+	}
+	return fmt.Sprintf("purego: field %s of %s: %s on %s/%s", e.Field, e.Struct, e.Reason, runtime.GOOS, runtime.GOARCH)
+}
+
+// ValidateLayout reports whether the struct type ty has the same layout as the C struct described by spec.
+//
+// Every field must have a type with a C equivalent and must be at the offset the C layout rules of the
+// current GOARCH place it. This catches missing padding fields and types that C aligns differently than Go.
+// Fields can carry the expected C offset and size in a tag which is checked as well:
+//
+//	type Event struct {
+//		Type      uint32 `purego:"offset=0,size=4"`
+//		_         [4]byte
+//		Timestamp uint64 `purego:"offset=8,size=8"`
+//	}
+//
+// spec may be nil to only check the layout rules and the tags. Otherwise, its Size, Align and Fields
+// are compared to the Go struct. It returns a *LayoutError naming the first field that doesn't match.
+//
+// RegisterFunc runs the same check with a nil spec for struct arguments and results that have tags.
+func ValidateLayout(ty reflect.Type, spec *Layout) error {
+	if ty.Kind() != reflect.Struct {
+		return fmt.Errorf("purego: %s is not a struct type", ty)
+	}
+	layoutErr := func(field, format string, args ...any) error {
+		return &LayoutError{Struct: ty, Field: field, Reason: fmt.Sprintf(format, args...)}
+	}
+	size, align, err := validateStruct(ty)
+	if err != nil {
+		return err
+	}
+	if spec == nil {
+		return nil
+	}
+	for _, want := range spec.Fields {
+		f, ok := ty.FieldByName(want.Name)
+		if !ok || len(f.Index) != 1 {
+			return layoutErr(want.Name, "no such field")
+		}
+		if f.Offset != want.Offset {
+			return layoutErr(f.Name, "offset is %d wanted %d", f.Offset, want.Offset)
+		}
+		if want.Size != 0 && f.Type.Size() != want.Size {
+			return layoutErr(f.Name, "size is %d wanted %d", f.Type.Size(), want.Size)
+		}
+	}
+	if spec.Size != 0 && size != spec.Size {
+		return layoutErr("", "size is %d wanted %d", size, spec.Size)
+	}
+	if spec.Align != 0 && align != spec.Align {
+		return layoutErr("", "alignment is %d wanted %d", align, spec.Align)
+	}
+	return nil
+}
+
+// checkLayoutTags runs ValidateLayout on ty if it has purego tags and returns
+// why it doesn't match or an empty string.
+func checkLayoutTags(ty reflect.Type) string {
+	if !hasLayoutTags(ty) {
+		return ""
+	}
+	err := ValidateLayout(ty, nil)
+	if err == nil {
+		return ""
+	}
+	if e, ok := err.(*LayoutError); ok {
+		if e.Field == "" {
+			return fmt.Sprintf("%s: %s", e.Struct, e.Reason)
+		}
+		return fmt.Sprintf("field %s of %s: %s", e.Field, e.Struct, e.Reason)
+	}
+	return err.Error()
+}
+
+// hasLayoutTags reports whether ty or any struct inside of it has a purego tag.
+func hasLayoutTags(ty reflect.Type) bool {
+	switch ty.Kind() {
+	case reflect.Array:
+		return hasLayoutTags(ty.Elem())
+	case reflect.Struct:
+		for i := 0; i < ty.NumField(); i++ {
+			f := ty.Field(i)
+			if _, ok := f.Tag.Lookup("purego"); ok || hasLayoutTags(f.Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateStruct checks the fields of ty against the C layout rules and their tags.
+// It returns the size and alignment C uses for ty.
+func validateStruct(ty reflect.Type) (size, align uintptr, err error) {
+	layoutErr := func(field, format string, args ...any) error {
+		return &LayoutError{Struct: ty, Field: field, Reason: fmt.Sprintf(format, args...)}
+	}
+	align = 1
+	var offset uintptr
+	for i := 0; i < ty.NumField(); i++ {
+		f := ty.Field(i)
+		fsize, falign, err := cSizeAlign(f.Type)
+		if err != nil {
+			if _, ok := err.(*LayoutError); ok {
+				return 0, 0, err
+			}
+			return 0, 0, layoutErr(f.Name, "%v", err)
+		}
+		offset = (offset + falign - 1) &^ (falign - 1)
+		if f.Offset != offset {
+			return 0, 0, layoutErr(f.Name, "offset is %d but C places it at %d", f.Offset, offset)
+		}
+		if tag, ok := f.Tag.Lookup("purego"); ok {
+			wantOffset, wantSize, err := parseLayoutTag(tag)
+			if err != nil {
+				return 0, 0, layoutErr(f.Name, "%v", err)
+			}
+			if wantOffset >= 0 && f.Offset != uintptr(wantOffset) {
+				return 0, 0, layoutErr(f.Name, "offset is %d wanted %d", f.Offset, wantOffset)
+			}
+			if wantSize >= 0 && f.Type.Size() != uintptr(wantSize) {
+				return 0, 0, layoutErr(f.Name, "size is %d wanted %d", f.Type.Size(), wantSize)
+			}
+		}
+		offset += fsize
+		if falign > align {
+			align = falign
+		}
+	}
+	size = (offset + align - 1) &^ (align - 1)
+	if ty.Size() != size {
+		field := ""
+		if n := ty.NumField(); n > 0 && ty.Field(n-1).Type.Size() == 0 {
+			// Go pads a struct that ends in a zero-sized field
+			field = ty.Field(n - 1).Name
+		}
+		return 0, 0, layoutErr(field, "struct size is %d but C's is %d", ty.Size(), size)
+	}
+	return size, align, nil
+}
+
+// cSizeAlign returns the size and alignment of the C type that is equivalent to ty.
+func cSizeAlign(ty reflect.Type) (size, align uintptr, err error) {
+	switch ty.Kind() {
+	case reflect.Bool, reflect.Int8, reflect.Uint8:
+		return 1, 1, nil
+	case reflect.Int16, reflect.Uint16:
+		return 2, 2, nil
+	case reflect.Int32, reflect.Uint32, reflect.Float32:
+		return 4, 4, nil
+	case reflect.Complex64:
+		return 8, 4, nil
+	case reflect.Int64, reflect.Uint64, reflect.Float64:
+		return 8, align64(), nil
+	case reflect.Complex128:
+		return 16, align64(), nil
+	case reflect.Int, reflect.Uint, reflect.Uintptr, reflect.Ptr, reflect.UnsafePointer:
+		return unsafe.Sizeof(uintptr(0)), unsafe.Alignof(uintptr(0)), nil
+	case reflect.Array:
+		size, align, err := cSizeAlign(ty.Elem())
+		if err != nil {
+			return 0, 0, err
+		}
+		return size * uintptr(ty.Len()), align, nil
+	case reflect.Struct:
+		return validateStruct(ty)
+	}
+	return 0, 0, fmt.Errorf("%s has no C equivalent", ty.Kind())
+}
+
+// align64 is the alignment C uses for 8 byte integers and doubles inside of structs.
+// Only the System V ABI for 386 aligns them to 4 bytes while Go does so on all 32bit platforms.
+func align64() uintptr {
+	if runtime.GOARCH == "386" && runtime.GOOS != "windows" {
+		return 4
+	}
+	return 8
+}
+
+// parseLayoutTag parses a tag like "offset=16,size=4". Missing values are -1.
+func parseLayoutTag(tag string) (offset, size int, err error) {
+	offset, size = -1, -1
+	for _, kv := range strings.Split(tag, ",") {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return 0, 0, fmt.Errorf("malformed purego tag %q", tag)
+		}
+		n, err := strconv.ParseUint(value, 0, 32)
+		if err != nil {
+			return 0, 0, fmt.Errorf("malformed purego tag %q: %v", tag, err)
+		}
+		switch key {
+		case "offset":
+			offset = int(n)
+		case "size":
+			size = int(n)
+		default:
+			return 0, 0, fmt.Errorf("unknown key %q in purego tag %q", key, tag)
+		}
+	}
+	return offset, size, nil
+}
@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+package purego_test
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/ebitengine/purego"
+)
+
+func TestValidateLayout(t *testing.T) {
+	if unsafe.Sizeof(uintptr(0)) != 8 {
+		t.Skip("the offsets below are for 64bit platforms")
+	}
+	{
+		type Event struct {
+			Type      uint32 `purego:"offset=0,size=4"`
+			_         [4]byte
+			Timestamp uint64 `purego:"offset=8,size=8"`
+			Data      [3]float32
+		}
+		spec := &purego.Layout{
+			Size:  32,
+			Align: 8,
+			Fields: []purego.FieldLayout{
+				{Name: "Type", Offset: 0, Size: 4},
+				{Name: "Timestamp", Offset: 8, Size: 8},
+				{Name: "Data", Offset: 16, Size: 12},
+			},
+		}
+		if err := purego.ValidateLayout(reflect.TypeOf(Event{}), spec); err != nil {
+			t.Errorf("ValidateLayout returned %v wanted nil", err)
+		}
+		spec.Fields[2].Offset = 20
+		var layoutErr *purego.LayoutError
+		if err := purego.ValidateLayout(reflect.TypeOf(Event{}), spec); !errors.As(err, &layoutErr) || layoutErr.Field != "Data" {
+			t.Errorf("ValidateLayout returned %v wanted an error for Data", err)
+		}
+	}
+	{
+		type BadTag struct {
+			A uint8
+			B uint32 `purego:"offset=1"`
+		}
+		var layoutErr *purego.LayoutError
+		if err := purego.ValidateLayout(reflect.TypeOf(BadTag{}), nil); !errors.As(err, &layoutErr) || layoutErr.Field != "B" {
+			t.Errorf("ValidateLayout returned %v wanted an error for B", err)
+		}
+	}
+	{
+		type Inner struct {
+			X, Y int32
+			Z    int32 `purego:"size=8"`
+		}
+		type Outer struct {
+			A Inner
+		}
+		var layoutErr *purego.LayoutError
+		if err := purego.ValidateLayout(reflect.TypeOf(Outer{}), nil); !errors.As(err, &layoutErr) || layoutErr.Field != "Z" || layoutErr.Struct != reflect.TypeOf(Inner{}) {
+			t.Errorf("ValidateLayout returned %v wanted an error for Inner.Z", err)
+		}
+	}
+	{
+		// Go pads a struct that ends in a zero-sized field but C doesn't
+		type Trailing struct {
+			A int64
+			B struct{}
+		}
+		var layoutErr *purego.LayoutError
+		if err := purego.ValidateLayout(reflect.TypeOf(Trailing{}), nil); !errors.As(err, &layoutErr) || layoutErr.Field != "B" {
+			t.Errorf("ValidateLayout returned %v wanted an error for B", err)
+		}
+	}
+	{
+		type NoC struct {
+			S string
+		}
+		if err := purego.ValidateLayout(reflect.TypeOf(NoC{}), nil); err == nil || !strings.Contains(err.Error(), "no C equivalent") {
+			t.Errorf("ValidateLayout returned %v wanted an error for S", err)
+		}
+	}
+	{
+		type Unknown struct {
+			A int32 `purego:"offset=0,aligned=4"`
+		}
+		if err := purego.ValidateLayout(reflect.TypeOf(Unknown{}), nil); err == nil {
+			t.Errorf("ValidateLayout returned nil wanted an error for the unknown tag key")
+		}
+	}
+}
+
+func TestRegisterFunc_layoutTags(t *testing.T) {
+	if runtime.GOOS != "darwin" && runtime.GOOS != "linux" || runtime.GOARCH != "amd64" && runtime.GOARCH != "arm64" && runtime.GOARCH != "loong64" {
+		t.Skip("struct arguments are not supported on " + runtime.GOOS + "/" + runtime.GOARCH)
+	}
+	type Rect struct {
+		X, Y float64
+		W    float64 `purego:"offset=20"`
+	}
+	var fn func(Rect) float64
+	err := purego.CheckSignature(reflect.TypeOf(fn))
+	var sigErr *purego.SignatureError
+	if !errors.As(err, &sigErr) || sigErr.Index != 0 || !strings.Contains(sigErr.Reason, "field W") {
+		t.Errorf("CheckSignature returned %v wanted an error for field W", err)
+	}
+}
@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build darwin || freebsd || linux || netbsd
+
+package purego
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// LazyLibrary is a shared library that is only loaded by Dlopen when it is first used.
+// It is the Unix counterpart of [golang.org/x/sys/windows.LazyDLL] and lets package-level
+// bindings to optional libraries be declared without loading them at init.
+//
+//	var (
+//		libz        = purego.NewLazyLibrary("libz.so.1")
+//		zlibVersion = libz.NewProc("zlibVersion")
+//	)
+//
+//	var ZlibVersion func() string
+//
+//	func init() {
+//		zlibVersion.Register(&ZlibVersion)
+//	}
+type LazyLibrary struct {
+	Name string // the path passed to Dlopen
+	Mode int    // the mode passed to Dlopen
+
+	mu     sync.Mutex
+	handle uintptr // accessed atomically once it is non-zero
+}
+
+// NewLazyLibrary creates a new LazyLibrary for the library name that is opened with
+// RTLD_NOW|RTLD_GLOBAL. Change Mode before the library is used for different flags.
+func NewLazyLibrary(name string) *LazyLibrary {
+	return &LazyLibrary{Name: name, Mode: RTLD_NOW | RTLD_GLOBAL}
+}
+
+// Load opens the library if it isn't opened yet. It returns the error of Dlopen and
+// tries again on the next call if it fails.
+func (l *LazyLibrary) Load() error {
+	if atomic.LoadUintptr(&l.handle) != 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.handle != 0 {
+		return nil
+	}
+	h, err := Dlopen(l.Name, l.Mode)
+	if err != nil {
+		return err
+	}
+	atomic.StoreUintptr(&l.handle, h)
+	return nil
+}
+
+// Handle returns the handle of the library for Dlsym. It panics if it can't be loaded.
+func (l *LazyLibrary) Handle() uintptr {
+	if err := l.Load(); err != nil {
+		panic(err)
+	}
+	return l.handle
+}
+
+// NewProc returns a LazyProc for the symbol name in l. Neither the library is loaded nor
+// the symbol is looked up until the LazyProc is used.
+func (l *LazyLibrary) NewProc(name string) *LazyProc {
+	return &LazyProc{Name: name, l: l}
+}
+
+// LazyProc is a symbol of a LazyLibrary that is looked up when it is first used.
+type LazyProc struct {
+	Name string
+
+	mu   sync.Mutex
+	l    *LazyLibrary
+	addr uintptr // accessed atomically once it is non-zero
+}
+
+// Find loads the library and looks up the symbol if that hasn't happened yet.
+// It returns an error if either of them fails which makes it a way to check whether
+// an optional function is available.
+func (p *LazyProc) Find() error {
+	if atomic.LoadUintptr(&p.addr) != 0 {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.addr != 0 {
+		return nil
+	}
+	if err := p.l.Load(); err != nil {
+		return err
+	}
+	addr, err := Dlsym(p.l.handle, p.Name)
+	if err != nil {
+		return err
+	}
+	atomic.StoreUintptr(&p.addr, addr)
+	return nil
+}
+
+// Addr returns the address of the symbol. It panics if Find fails.
+func (p *LazyProc) Addr() uintptr {
+	if err := p.Find(); err != nil {
+		panic(err)
+	}
+	return p.addr
+}
+
+// Register sets fptr to a function that looks up the symbol the first time it is called
+// and then calls it like a function registered with RegisterFunc. The signature of fptr is
+// checked right away but the library isn't loaded. The function panics like Addr if the
+// symbol can't be found, so call Find first for optional symbols.
+func (p *LazyProc) Register(fptr any) {
+	ptr := reflect.ValueOf(fptr)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Func {
+		panic(errors.New("purego: fptr must be a function pointer"))
+	}
+	fn := ptr.Elem()
+	ty := fn.Type()
+	if err := CheckSignature(ty); err != nil {
+		panic(err)
+	}
+	var impl atomic.Value // func(args []reflect.Value) []reflect.Value
+	fn.Set(reflect.MakeFunc(ty, func(args []reflect.Value) []reflect.Value {
+		call, _ := impl.Load().(func(args []reflect.Value) []reflect.Value)
+		if call == nil {
+			call = callFunc(ty, p.Addr())
+			impl.Store(call)
+		}
+		return call(args)
+	}))
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build darwin || freebsd || linux || netbsd
+
+package purego_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/ebitengine/purego"
+)
+
+func TestLazyLibrary(t *testing.T) {
+	library, err := getSystemLibrary()
+	if err != nil {
+		t.Fatalf("couldn't get system library: %s", err)
+	}
+	libc := purego.NewLazyLibrary(library)
+	strlen := libc.NewProc("strlen")
+
+	var fn func(string) int
+	strlen.Register(&fn)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := fn("purego"); got != 6 {
+				t.Errorf("strlen returned %d wanted 6", got)
+			}
+		}()
+	}
+	wg.Wait()
+	if strlen.Addr() == 0 {
+		t.Errorf("Addr returned 0")
+	}
+
+	if err := libc.NewProc("purego_does_not_exist").Find(); err == nil {
+		t.Errorf("Find succeeded for a missing symbol")
+	}
+	missing := purego.NewLazyLibrary("libpurego_does_not_exist.so")
+	if err := missing.NewProc("strlen").Find(); err == nil {
+		t.Errorf("Find succeeded for a missing library")
+	}
+}
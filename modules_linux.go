@@ -0,0 +1,352 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build (amd64 || arm64 || loong64) && !android && !faketime
+
+package purego
+
+import (
+	"debug/elf"
+	"errors"
+	"os"
+	"sync"
+	"unsafe"
+
+	"github.com/ebitengine/purego/internal/strings"
+)
+
+var fnDlIteratePhdr func(callback uintptr, data unsafe.Pointer) int32
+
+func init() {
+	RegisterFunc(&fnDlIteratePhdr, dlIteratePhdrABI0)
+}
+
+// dlPhdrInfo is the beginning of struct dl_phdr_info.
+type dlPhdrInfo struct {
+	addr  uintptr
+	name  uintptr
+	phdr  uintptr
+	phnum uint16
+}
+
+// Module is a shared object loaded into the process.
+type Module struct {
+	Path   string  // the path of the shared object or "" for the main program
+	Base   uintptr // the difference between the addresses in the shared object and in memory
+	Handle uintptr // the handle Dlopen returns for it or 0 if the dynamic linker has none
+
+	phdr  uintptr // the program headers in memory
+	phnum int
+}
+
+var (
+	modulesMu       sync.Mutex
+	modules         []Module // filled by modulesCallback while modulesMu is held
+	modulesCallback uintptr
+)
+
+// LoadedModules returns the shared objects loaded into the base namespace of the process
+// in load order, starting with the main program. It uses dl_iterate_phdr.
+func LoadedModules() ([]Module, error) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	if modulesCallback == 0 {
+		modulesCallback = NewCallback(func(info *dlPhdrInfo, size uintptr, data unsafe.Pointer) int32 {
+			// dl_iterate_phdr holds the lock of the dynamic linker so Dlopen must wait until it returns
+			modules = append(modules, Module{
+				Path:  strings.GoString(info.name),
+				Base:  info.addr,
+				phdr:  info.phdr,
+				phnum: int(info.phnum),
+			})
+			return 0
+		})
+	}
+	modules = nil
+	fnDlIteratePhdr(modulesCallback, nil)
+	list := modules
+	modules = nil
+	if len(list) == 0 {
+		return nil, errors.New("purego: dl_iterate_phdr found no modules")
+	}
+	for i := range list {
+		// the handle stays valid while the module is loaded so the reference isn't kept
+		if h, err := Dlopen(list[i].Path, RTLD_LAZY|RTLD_NOLOAD); err == nil {
+			list[i].Handle = h
+			Dlclose(h)
+		}
+	}
+	return list, nil
+}
+
+// ModuleSymbol is a symbol a module exports.
+type ModuleSymbol struct {
+	Name    string
+	Version string  // the version like "GLIBC_2.2.5" or "" if the symbol has none
+	Hidden  bool    // the version is not the default one which Dlsym returns
+	Addr    uintptr // the address in memory which is the resolver for GNU indirect functions
+	Size    uintptr
+}
+
+// Symbols returns the symbols m exports by walking its dynamic symbol table in memory through
+// the GNU or SysV hash table. Thread-local symbols are left out since they have no address.
+// m must still be loaded.
+func (m Module) Symbols() ([]ModuleSymbol, error) {
+	d, err := m.dynamic()
+	if err != nil {
+		return nil, err
+	}
+	if d.symtab == 0 || d.strtab == 0 {
+		return nil, errors.New("purego: " + m.name() + " has no dynamic symbol table")
+	}
+	var count uint32
+	switch {
+	case d.gnuHash != 0:
+		count = gnuHashSymbols(d.gnuHash)
+	case d.hash != 0:
+		count = *(*uint32)(at(d.hash + 4)) // nchain
+	default:
+		return nil, errors.New("purego: " + m.name() + " has no hash table")
+	}
+	versions := d.versions()
+
+	var syms []ModuleSymbol
+	for i := uint32(1); i < count; i++ {
+		if s, ok := d.symbol(i, m.Base); ok {
+			if d.versym != 0 {
+				s.Version = versions[*(*uint16)(at(d.versym + uintptr(i)*2))&0x7fff]
+			}
+			syms = append(syms, s)
+		}
+	}
+	return syms, nil
+}
+
+// symbol reads the symbol at index i of the dynamic symbol table without its version.
+// It reports false for symbols that Symbols leaves out.
+func (d dynamicSection) symbol(i uint32, base uintptr) (ModuleSymbol, bool) {
+	sym := (*elf.Sym64)(at(d.symtab + uintptr(i)*unsafe.Sizeof(elf.Sym64{})))
+	bind, typ := elf.ST_BIND(sym.Info), elf.ST_TYPE(sym.Info)
+	if sym.Shndx == uint16(elf.SHN_UNDEF) || sym.Name == 0 || bind == elf.STB_LOCAL ||
+		typ == elf.STT_TLS || typ == elf.STT_SECTION || typ == elf.STT_FILE {
+		return ModuleSymbol{}, false
+	}
+	s := ModuleSymbol{
+		Name: strings.GoString(d.strtab + uintptr(sym.Name)),
+		Addr: uintptr(sym.Value),
+		Size: uintptr(sym.Size),
+	}
+	if sym.Shndx != uint16(elf.SHN_ABS) {
+		s.Addr += base
+	}
+	if d.versym != 0 {
+		s.Hidden = *(*uint16)(at(d.versym + uintptr(i)*2))&0x8000 != 0
+	}
+	return s, true
+}
+
+// lookup returns the indexes of the symbols that may be name from the GNU or SysV hash table.
+// The names of the candidates still have to be compared.
+func (d dynamicSection) lookup(name string) []uint32 {
+	var candidates []uint32
+	switch {
+	case d.gnuHash != 0:
+		h := uint32(5381)
+		for i := 0; i < len(name); i++ {
+			h = h*33 + uint32(name[i])
+		}
+		nbuckets := *(*uint32)(at(d.gnuHash))
+		symoffset := *(*uint32)(at(d.gnuHash + 4))
+		bloomSize := *(*uint32)(at(d.gnuHash + 8))
+		buckets := d.gnuHash + 16 + uintptr(bloomSize)*8
+		chains := buckets + uintptr(nbuckets)*4
+		i := *(*uint32)(at(buckets + uintptr(h%nbuckets)*4))
+		if i < symoffset {
+			return nil
+		}
+		for ; ; i++ {
+			// the lowest bit of the hashes in the chain marks its end
+			chain := *(*uint32)(at(chains + uintptr(i-symoffset)*4))
+			if chain|1 == h|1 {
+				candidates = append(candidates, i)
+			}
+			if chain&1 != 0 {
+				return candidates
+			}
+		}
+	case d.hash != 0:
+		var h uint32
+		for i := 0; i < len(name); i++ {
+			h = h<<4 + uint32(name[i])
+			h ^= h >> 24 & 0xf0
+		}
+		h &= 0x0fffffff
+		nbucket := *(*uint32)(at(d.hash))
+		buckets := d.hash + 8
+		chains := buckets + uintptr(nbucket)*4
+		for i := *(*uint32)(at(buckets + uintptr(h%nbucket)*4)); i != 0; i = *(*uint32)(at(chains + uintptr(i)*4)) {
+			candidates = append(candidates, i)
+		}
+	}
+	return candidates
+}
+
+// symbolSize returns the size of the symbol name at addr from the dynamic symbol table of
+// the object Dladdr finds for addr.
+func symbolSize(addr uintptr, name string) (uintptr, error) {
+	info, err := Dladdr(addr)
+	if err != nil {
+		return 0, err
+	}
+	m, err := moduleAt(info.Base)
+	if err != nil {
+		return 0, err
+	}
+	m.Path = info.Path
+	d, err := m.dynamic()
+	if err != nil {
+		return 0, err
+	}
+	for _, i := range d.lookup(name) {
+		if s, ok := d.symbol(i, m.Base); ok && s.Name == name && s.Addr == addr {
+			if s.Size == 0 {
+				return 0, errors.New("purego: the size of " + name + " in " + info.Path + " is unknown")
+			}
+			return s.Size, nil
+		}
+	}
+	return 0, errors.New("purego: " + name + " is not in the dynamic symbol table of " + info.Path)
+}
+
+// moduleAt returns the Module whose ELF header is mapped at start like the Base that Dladdr returns.
+func moduleAt(start uintptr) (Module, error) {
+	hdr := (*elf.Header64)(at(start))
+	if string(hdr.Ident[:4]) != elf.ELFMAG || elf.Class(hdr.Ident[elf.EI_CLASS]) != elf.ELFCLASS64 {
+		return Module{}, errors.New("purego: no ELF header is mapped at the start of the object")
+	}
+	m := Module{phdr: start + uintptr(hdr.Phoff), phnum: int(hdr.Phnum)}
+	// the object is mapped from the page of the first loadable segment
+	for i := 0; i < m.phnum; i++ {
+		prog := (*elf.Prog64)(at(m.phdr + uintptr(i)*unsafe.Sizeof(elf.Prog64{})))
+		if elf.ProgType(prog.Type) == elf.PT_LOAD {
+			m.Base = start - uintptr(prog.Vaddr)&^uintptr(os.Getpagesize()-1)
+			return m, nil
+		}
+	}
+	return Module{}, errors.New("purego: the object has no loadable segment")
+}
+
+func (m Module) name() string {
+	if m.Path == "" {
+		return "the main program"
+	}
+	return m.Path
+}
+
+// dynamicSection holds the addresses of the dynamic section entries Symbols uses.
+type dynamicSection struct {
+	symtab, strtab, hash, gnuHash uintptr
+	versym, verdef                uintptr
+	verdefnum                     int
+}
+
+func (m Module) dynamic() (dynamicSection, error) {
+	var d dynamicSection
+	var dyn uintptr
+	for i := 0; i < m.phnum; i++ {
+		prog := (*elf.Prog64)(at(m.phdr + uintptr(i)*unsafe.Sizeof(elf.Prog64{})))
+		if elf.ProgType(prog.Type) == elf.PT_DYNAMIC {
+			dyn = m.Base + uintptr(prog.Vaddr)
+			break
+		}
+	}
+	if dyn == 0 {
+		return d, errors.New("purego: " + m.name() + " has no dynamic section")
+	}
+	// glibc relocates the addresses in the dynamic section of shared objects but not the
+	// ones of the vDSO and musl relocates none of them.
+	ptr := func(v uint64) uintptr {
+		if uintptr(v) < m.Base {
+			return m.Base + uintptr(v)
+		}
+		return uintptr(v)
+	}
+	for ; ; dyn += unsafe.Sizeof(elf.Dyn64{}) {
+		entry := (*elf.Dyn64)(at(dyn))
+		switch elf.DynTag(entry.Tag) {
+		case elf.DT_NULL:
+			return d, nil
+		case elf.DT_SYMTAB:
+			d.symtab = ptr(entry.Val)
+		case elf.DT_STRTAB:
+			d.strtab = ptr(entry.Val)
+		case elf.DT_HASH:
+			d.hash = ptr(entry.Val)
+		case elf.DT_GNU_HASH:
+			d.gnuHash = ptr(entry.Val)
+		case elf.DT_VERSYM:
+			d.versym = ptr(entry.Val)
+		case elf.DT_VERDEF:
+			d.verdef = ptr(entry.Val)
+		case elf.DT_VERDEFNUM:
+			d.verdefnum = int(entry.Val)
+		}
+	}
+}
+
+// versions returns the names of the version definitions by their index.
+func (d dynamicSection) versions() map[uint16]string {
+	versions := map[uint16]string{}
+	if d.verdef == 0 {
+		return versions
+	}
+	def := d.verdef
+	for i := 0; i < d.verdefnum; i++ {
+		// Elf64_Verdef and the Elf64_Verdaux of its name
+		ndx := *(*uint16)(at(def + 4))
+		aux := *(*uint32)(at(def + 12))
+		next := *(*uint32)(at(def + 16))
+		name := *(*uint32)(at(def + uintptr(aux)))
+		versions[ndx] = strings.GoString(d.strtab + uintptr(name))
+		if next == 0 {
+			break
+		}
+		def += uintptr(next)
+	}
+	return versions
+}
+
+// gnuHashSymbols returns the number of symbols in the dynamic symbol table by finding the
+// end of the last hash chain of the GNU hash table at addr.
+func gnuHashSymbols(addr uintptr) uint32 {
+	nbuckets := *(*uint32)(at(addr))
+	symoffset := *(*uint32)(at(addr + 4))
+	bloomSize := *(*uint32)(at(addr + 8))
+	buckets := addr + 16 + uintptr(bloomSize)*8
+	chains := buckets + uintptr(nbuckets)*4
+
+	var last uint32
+	for i := uint32(0); i < nbuckets; i++ {
+		if b := *(*uint32)(at(buckets + uintptr(i)*4)); b > last {
+			last = b
+		}
+	}
+	if last < symoffset {
+		return symoffset
+	}
+	// the last symbol of a chain has the lowest bit set
+	for *(*uint32)(at(chains + uintptr(last-symoffset)*4))&1 == 0 {
+		last++
+	}
+	return last + 1
+}
+
+// at returns a pointer to addr which is memory of the dynamic linker outside of the Go heap.
+func at(addr uintptr) unsafe.Pointer {
+	// We take the address and then dereference it to trick go vet from creating a possible miss-use of unsafe.Pointer
+	return *(*unsafe.Pointer)(unsafe.Pointer(&addr))
+}
+
+//go:linkname dl_iterate_phdr dl_iterate_phdr
+var dl_iterate_phdr uint8
+var dlIteratePhdrABI0 = uintptr(unsafe.Pointer(&dl_iterate_phdr))
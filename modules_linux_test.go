@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build (amd64 || arm64 || loong64) && !android
+
+package purego_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ebitengine/purego"
+)
+
+func findModule(t *testing.T, match func(purego.Module) bool) purego.Module {
+	t.Helper()
+	modules, err := purego.LoadedModules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modules[0].Path != "" {
+		t.Errorf("the first module is %q wanted the main program", modules[0].Path)
+	}
+	for _, m := range modules {
+		if match(m) {
+			return m
+		}
+	}
+	t.Fatalf("the module is not in %v", modules)
+	return purego.Module{}
+}
+
+func TestLoadedModules(t *testing.T) {
+	libc := openLibc(t)
+	m := findModule(t, func(m purego.Module) bool { return m.Handle == libc })
+	if !strings.HasPrefix(filepath.Base(m.Path), "libc") || m.Base == 0 {
+		t.Errorf("the module of libc is %q at %#x", m.Path, m.Base)
+	}
+
+	syms, err := m.Symbols()
+	if err != nil {
+		t.Fatal(err)
+	}
+	puts, err := purego.Dlsym(libc, "puts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, s := range syms {
+		if s.Name == "puts" && !s.Hidden {
+			found = true
+			if s.Addr != puts || s.Size == 0 || !strings.HasPrefix(s.Version, "GLIBC_") {
+				t.Errorf("puts is %+v wanted the address %#x and a GLIBC version", s, puts)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("puts is not in the %d symbols of libc", len(syms))
+	}
+}
+
+func TestModuleSymbols(t *testing.T) {
+	libFileName := filepath.Join(t.TempDir(), "libsymbols.so")
+	if err := buildSharedLib("CC", libFileName, filepath.Join("testdata", "namespacetest", "namespace_test.c")); err != nil {
+		t.Fatal(err)
+	}
+	lib, err := purego.Dlopen(libFileName, purego.RTLD_NOW|purego.RTLD_LOCAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer purego.Dlclose(lib)
+
+	m := findModule(t, func(m purego.Module) bool { return m.Handle == lib })
+	if m.Path != libFileName {
+		t.Errorf("the module has path %q wanted %q", m.Path, libFileName)
+	}
+	syms, err := m.Symbols()
+	if err != nil {
+		t.Fatal(err)
+	}
+	increment, err := purego.Dlsym(lib, "increment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, s := range syms {
+		switch s.Name {
+		case "increment":
+			found = true
+			if s.Addr != increment || s.Version != "" {
+				t.Errorf("increment is %+v wanted the address %#x without a version", s, increment)
+			}
+		case "counter":
+			t.Errorf("the static variable counter is exported")
+		}
+	}
+	if !found {
+		t.Errorf("increment is not in %v", syms)
+	}
+}
+
+func TestLibVarHashStyles(t *testing.T) {
+	for _, style := range []string{"gnu", "sysv"} {
+		t.Run(style, func(t *testing.T) {
+			libFileName := filepath.Join(t.TempDir(), "libvar.so")
+			if err := buildSharedLib("CC", libFileName, filepath.Join("testdata", "vartest", "var_test.c"), "-Wl,--hash-style="+style); err != nil {
+				t.Fatal(err)
+			}
+			lib, err := purego.Dlopen(libFileName, purego.RTLD_NOW|purego.RTLD_LOCAL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer purego.Dlclose(lib)
+
+			counter, err := purego.LibVar[int32](lib, "purego_counter")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := counter.Load(); got != 7 {
+				t.Errorf("purego_counter is %d wanted 7", got)
+			}
+			values, err := purego.LibVar[[4]float64](lib, "purego_values")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := values.Load(); got != [4]float64{1, 2, 3, 4} {
+				t.Errorf("purego_values is %v wanted [1 2 3 4]", got)
+			}
+			if _, err := purego.LibVar[[3]float64](lib, "purego_values"); err == nil || !strings.Contains(err.Error(), "32 bytes") {
+				t.Errorf("LibVar[[3]float64] of purego_values returned %v wanted a size error", err)
+			}
+		})
+	}
+}
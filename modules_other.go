@@ -0,0 +1,11 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build darwin || freebsd || netbsd || (linux && ((!amd64 && !arm64 && !loong64) || android || faketime))
+
+package purego
+
+// symbolSize returns 0 as the size of symbols is unknown on this platform.
+func symbolSize(addr uintptr, name string) (uintptr, error) {
+	return 0, nil
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build darwin || freebsd || linux || netbsd || windows
+
+package purego
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SymbolsError is returned by RegisterFuncs and RegisterLibFuncs if required symbols
+// are missing or fields can't be registered.
+type SymbolsError struct {
+	Missing []string // the required symbols that weren't found with alternatives joined by "|"
+	Errors  []error  // why the other fields couldn't be registered
+}
+
+func (e *SymbolsError) Error() string {
+	var msgs []string
+	if len(e.Missing) > 0 {
+		msgs = append(msgs, "missing symbols "+strings.Join(e.Missing, ", "))
+	}
+	for _, err := range e.Errors {
+		msgs = append(msgs, strings.TrimPrefix(err.Error(), "purego: "))
+	}
+	return "purego: " + strings.Join(msgs, "; ")
+}
+
+// RegisterLibFuncs is a wrapper around RegisterFuncs that looks up the symbols with Dlsym(handle, name).
+func RegisterLibFuncs(dst any, handle uintptr) error {
+	return RegisterFuncs(dst, func(name string) uintptr {
+		sym, err := loadSymbol(handle, name)
+		if err != nil {
+			return 0
+		}
+		return sym
+	})
+}
+
+// RegisterFuncs calls RegisterFunc for every field of the struct dst points to that has a function type.
+// The address of each function is the result of resolve for its symbol, which returns 0 if the symbol
+// doesn't exist. This allows loaders like glXGetProcAddress or vkGetInstanceProcAddr to be used.
+//
+// The symbol is the name of the field unless the field has a purego tag:
+//
+//	type SDL struct {
+//		CreateWindow  func(title string, w, h int32, flags uint64) uintptr `purego:"sym=SDL_CreateWindow"`
+//		GenBuffers    func(n int32, buffers *uint32)                       `purego:"sym=glGenBuffers|glGenBuffersARB"`
+//		SetHint       func(name, value string) bool                        `purego:"sym=SDL_SetHint,optional"`
+//		Unused        func()                                               `purego:"-"`
+//	}
+//
+// Alternative names are separated by "|" and tried in order. The field of an optional symbol that doesn't
+// exist is left nil. Every field that can be registered is set, even if others can't. In that case it returns
+// a *SymbolsError that lists every missing required symbol and every field with an unsupported type or tag.
+func RegisterFuncs(dst any, resolve func(name string) uintptr) error {
+	ptr := reflect.ValueOf(dst)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Struct {
+		return errors.New("purego: dst must be a pointer to a struct")
+	}
+	v := ptr.Elem()
+	ty := v.Type()
+	var symErr SymbolsError
+	for i := 0; i < ty.NumField(); i++ {
+		f := ty.Field(i)
+		tag, hasTag := f.Tag.Lookup("purego")
+		if tag == "-" || (f.Type.Kind() != reflect.Func && !hasTag) {
+			continue
+		}
+		names, optional, err := parseSymbolTag(f.Name, tag)
+		switch {
+		case err != nil:
+		case f.Type.Kind() != reflect.Func:
+			err = fmt.Errorf("%s is not a function", f.Type)
+		case !f.IsExported():
+			err = errors.New("field is not exported")
+		default:
+			err = CheckSignature(f.Type)
+		}
+		if err != nil {
+			symErr.Errors = append(symErr.Errors, fmt.Errorf("field %s: %v", f.Name, err))
+			continue
+		}
+		var cfn uintptr
+		for _, name := range names {
+			if cfn = resolve(name); cfn != 0 {
+				break
+			}
+		}
+		if cfn == 0 {
+			if !optional {
+				symErr.Missing = append(symErr.Missing, strings.Join(names, "|"))
+			}
+			continue
+		}
+		registerFunc(v.Field(i), f.Type, cfn)
+	}
+	if len(symErr.Missing) > 0 || len(symErr.Errors) > 0 {
+		return &symErr
+	}
+	return nil
+}
+
+// parseSymbolTag parses a tag like "sym=glGenBuffers|glGenBuffersARB,optional" of the field called field.
+func parseSymbolTag(field, tag string) (names []string, optional bool, err error) {
+	names = []string{field}
+	if tag == "" {
+		return names, false, nil
+	}
+	for _, kv := range strings.Split(tag, ",") {
+		key, value, hasValue := strings.Cut(kv, "=")
+		switch {
+		case key == "sym" && hasValue && value != "":
+			names = strings.Split(value, "|")
+		case key == "optional" && !hasValue:
+			optional = true
+		default:
+			return nil, false, fmt.Errorf("malformed purego tag %q", tag)
+		}
+	}
+	return names, optional, nil
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+package purego_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ebitengine/purego"
+	"github.com/ebitengine/purego/internal/load"
+)
+
+func TestRegisterLibFuncs(t *testing.T) {
+	library, err := getSystemLibrary()
+	if err != nil {
+		t.Fatalf("couldn't get system library: %s", err)
+	}
+	libc, err := load.OpenLibrary(library)
+	if err != nil {
+		t.Fatalf("failed to dlopen: %s", err)
+	}
+
+	var funcs struct {
+		Strlen   func(string) int
+		Strcmp   func(a, b string) int32 `purego:"sym=purego_strcmp|strcmp"`
+		Optional func()                  `purego:"sym=purego_optional,optional"`
+		Skipped  func()                  `purego:"-"`
+		Count    int
+	}
+	var symErr *purego.SymbolsError
+	if err := purego.RegisterLibFuncs(&funcs, libc); !errors.As(err, &symErr) {
+		t.Fatalf("RegisterLibFuncs returned %v wanted a *SymbolsError", err)
+	}
+	if !reflect.DeepEqual(symErr.Missing, []string{"Strlen"}) {
+		t.Errorf("RegisterLibFuncs reported %q missing wanted Strlen", symErr.Missing)
+	}
+	if funcs.Strcmp == nil || funcs.Strcmp("purego", "purego") != 0 {
+		t.Errorf("RegisterLibFuncs didn't register Strcmp through its alternative name")
+	}
+	if funcs.Optional != nil || funcs.Skipped != nil {
+		t.Errorf("RegisterLibFuncs registered an optional or skipped field")
+	}
+
+	var resolved []string
+	err = purego.RegisterFuncs(&funcs, func(name string) uintptr {
+		resolved = append(resolved, name)
+		if name == "Strlen" {
+			name = "strlen"
+		}
+		sym, err := load.OpenSymbol(libc, name)
+		if err != nil {
+			return 0
+		}
+		return sym
+	})
+	if err != nil {
+		t.Fatalf("RegisterFuncs failed: %v", err)
+	}
+	if got := funcs.Strlen("purego"); got != 6 {
+		t.Errorf("strlen returned %d wanted 6", got)
+	}
+	if want := []string{"Strlen", "purego_strcmp", "strcmp", "purego_optional"}; !reflect.DeepEqual(resolved, want) {
+		t.Errorf("RegisterFuncs resolved %q wanted %q", resolved, want)
+	}
+
+	var bad struct {
+		Count int            `purego:"sym=count"`
+		Chan  func(chan int) `purego:"sym=strlen"`
+		Tag   func()         `purego:"sym"`
+	}
+	err = purego.RegisterLibFuncs(&bad, libc)
+	if !errors.As(err, &symErr) || len(symErr.Errors) != 3 || len(symErr.Missing) != 0 {
+		t.Errorf("RegisterLibFuncs returned %v wanted an error for each field", err)
+	}
+}
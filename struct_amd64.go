@@ -101,6 +101,8 @@ func addStruct(v reflect.Value, numInts, numFloats, numStack *int, addInt, addFl
 		savedNumStack  = *numStack
 	)
 	placeOnStack := postMerger(v.Type()) || !tryPlaceRegister(v, addFloat, addInt)
+	// If there are not enough registers for every eightbyte the whole struct is passed on the stack
+	placeOnStack = placeOnStack || *numStack != savedNumStack
 	if placeOnStack {
 		// reset any values placed in registers
 		*numFloats = savedNumFloats
@@ -160,6 +162,13 @@ func tryPlaceRegister(v reflect.Value, addFloat func(uintptr), addInt func(uintp
 			} else {
 				f = v.Index(i)
 			}
+			// align the field to its natural alignment within the eightbyte
+			align := byte(f.Type().Align()*8 - 1)
+			shift = (shift + align) &^ align
+			if shift >= 64 {
+				flushIfNeeded()
+				flushed = false
+			}
 			switch f.Kind() {
 			case reflect.Struct:
 				place(f)
@@ -169,9 +178,10 @@ func tryPlaceRegister(v reflect.Value, addFloat func(uintptr), addInt func(uintp
 				}
 				shift += 8
 				class |= _INTEGER
-			case reflect.Pointer:
-				ok = false
-				return
+			case reflect.Pointer, reflect.UnsafePointer:
+				val = uint64(f.Pointer())
+				shift = 64
+				class = _INTEGER
 			case reflect.Int8:
 				val |= uint64(f.Int()&0xFF) << shift
 				shift += 8
@@ -238,27 +248,59 @@ func tryPlaceRegister(v reflect.Value, addFloat func(uintptr), addInt func(uintp
 }
 
 func placeStack(v reflect.Value, addStack func(uintptr)) {
-	for i := 0; i < v.Type().NumField(); i++ {
-		f := v.Field(i)
-		switch f.Kind() {
-		case reflect.Pointer:
-			addStack(f.Pointer())
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			addStack(uintptr(f.Int()))
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-			addStack(uintptr(f.Uint()))
-		case reflect.Float32:
-			addStack(uintptr(math.Float32bits(float32(f.Float()))))
-		case reflect.Float64:
-			addStack(uintptr(math.Float64bits(f.Float())))
-		case reflect.Struct:
-			placeStack(f, addStack)
-		default:
-			panic("purego: unsupported kind " + f.Kind().String())
-		}
-	}
+	// Structs classified as MEMORY are copied onto the stack
+	// as-is, in eightbyte chunks, keeping the C layout of the fields.
+	addStructWords(v, addStack)
 }
 
 func placeRegisters(v reflect.Value, addFloat func(uintptr), addInt func(uintptr)) {
 	panic("purego: not needed on amd64")
 }
+
+// callbackStructArg is the inverse of addStruct for callbacks. It reads a struct of type ty
+// from the words nextInt, nextFloat and nextStack return in the order addStruct places them.
+func callbackStructArg(ty reflect.Type, numInts, numFloats *int, nextInt, nextFloat, nextStack func() uintptr) reflect.Value {
+	v := reflect.New(ty).Elem()
+	if ty.Size() == 0 {
+		return v
+	}
+	// the eightbytes are the memory of the struct in both registers and on the stack
+	words := make([]uintptr, roundUpTo8(ty.Size())/8)
+	var isFloat []bool // the class of each eightbyte in registers
+	var ints, floats int
+	if ty.Size() <= 8*8 && !postMerger(ty) {
+		if !tryPlaceRegister(v, func(uintptr) {
+			isFloat = append(isFloat, true)
+			floats++
+		}, func(uintptr) {
+			isFloat = append(isFloat, false)
+			ints++
+		}) {
+			isFloat = nil
+		}
+	}
+	if isFloat != nil && *numInts+ints <= numOfIntegerRegisters() && *numFloats+floats <= numOfFloatRegisters {
+		for i := range words {
+			if isFloat[i] {
+				words[i] = nextFloat()
+			} else {
+				words[i] = nextInt()
+			}
+		}
+	} else {
+		for i := range words {
+			words[i] = nextStack()
+		}
+	}
+	v.Set(reflect.NewAt(ty, unsafe.Pointer(&words[0])).Elem())
+	return v
+}
+
+// callbackStructResult places the struct result v of a callback in the registers getStruct reads.
+// v must be at most maxRegAllocStructSize bytes.
+func callbackStructResult(v reflect.Value, addInt, addFloat func(uintptr)) {
+	if v.Type().Size() == 0 {
+		return
+	}
+	tryPlaceRegister(v, addFloat, addInt)
+}
@@ -156,7 +156,7 @@ func placeRegisters(v reflect.Value, addFloat func(uintptr), addInt func(uintptr
 				shift = 0
 				flushed = true
 				class = _NO_CLASS
-			case reflect.Ptr:
+			case reflect.Ptr, reflect.UnsafePointer:
 				addInt(f.Pointer())
 				shift = 0
 				flushed = true
@@ -188,3 +188,110 @@ func placeStack(v reflect.Value, keepAlive []any, addInt func(uintptr)) []any {
 	addInt(uintptr(ptr))
 	return keepAlive
 }
+
+// callbackStructArg is the inverse of addStruct for callbacks. It reads a struct of type ty
+// from the words nextInt, nextFloat and nextStack return in the order addStruct places them.
+func callbackStructArg(ty reflect.Type, numInts, numFloats *int, nextInt, nextFloat, nextStack func() uintptr) reflect.Value {
+	v := reflect.New(ty).Elem()
+	if ty.Size() == 0 {
+		return v
+	}
+	if ty.Size() > 16 {
+		// placeStack passed a pointer to a copy of the struct
+		if ptr := nextInt(); ptr != 0 {
+			v.Set(reflect.NewAt(ty, *(*unsafe.Pointer)(unsafe.Pointer(&ptr))).Elem())
+		}
+		return v
+	}
+	var words []uintptr
+	placeRegisters(v, func(uintptr) {
+		words = append(words, nextFloat())
+	}, func(uintptr) {
+		words = append(words, nextInt())
+	})
+	takeRegisters(v, words)
+	return v
+}
+
+// takeRegisters is the inverse of placeRegisters. It sets the fields of v, which must be
+// addressable, from the words placeRegisters produces for it.
+func takeRegisters(v reflect.Value, words []uintptr) {
+	var i int // the word that holds the current field
+	var shift byte
+	class := _NO_CLASS
+	var take func(v reflect.Value)
+	take = func(v reflect.Value) {
+		var numFields int
+		if v.Kind() == reflect.Struct {
+			numFields = v.Type().NumField()
+		} else {
+			numFields = v.Type().Len()
+		}
+		for k := 0; k < numFields; k++ {
+			var f reflect.Value
+			if v.Kind() == reflect.Struct {
+				f = v.Field(k)
+			} else {
+				f = v.Index(k)
+			}
+			align := byte(f.Type().Align()*8 - 1)
+			shift = (shift + align) &^ align
+			if shift >= 64 {
+				shift = 0
+				i++
+				class = _NO_CLASS
+			}
+			ptr := unsafe.Pointer(f.UnsafeAddr())
+			switch f.Type().Kind() {
+			case reflect.Struct, reflect.Array:
+				take(f)
+			case reflect.Bool, reflect.Uint8, reflect.Int8:
+				*(*uint8)(ptr) = uint8(words[i] >> shift)
+				shift += 8
+				class |= _INT
+			case reflect.Uint16, reflect.Int16:
+				*(*uint16)(ptr) = uint16(words[i] >> shift)
+				shift += 16
+				class |= _INT
+			case reflect.Uint32, reflect.Int32:
+				*(*uint32)(ptr) = uint32(words[i] >> shift)
+				shift += 32
+				class |= _INT
+			case reflect.Float32:
+				if class == _FLOAT {
+					i++
+					shift = 0
+				}
+				*(*uint32)(ptr) = uint32(words[i] >> shift)
+				shift += 32
+				class |= _FLOAT
+			case reflect.Ptr, reflect.UnsafePointer:
+				*(*unsafe.Pointer)(ptr) = *(*unsafe.Pointer)(unsafe.Pointer(&words[i]))
+				i++
+				shift = 0
+				class = _NO_CLASS
+			default:
+				// the 8 byte integers and float64 have a word of their own
+				*(*uint64)(ptr) = uint64(words[i])
+				i++
+				shift = 0
+				class = _NO_CLASS
+			}
+		}
+	}
+	take(v)
+}
+
+// callbackStructResult places the struct result v of a callback in the registers getStruct reads.
+// v must fit in registers.
+func callbackStructResult(v reflect.Value, addInt, addFloat func(uintptr)) {
+	size := v.Type().Size()
+	if size == 0 {
+		return
+	}
+	if isAllFloats, _ := isAllSameFloat(v.Type()); isAllFloats {
+		addFloatFields(v, addFloat)
+		return
+	}
+	addStructWords(v, addInt)
+}
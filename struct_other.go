@@ -18,3 +18,11 @@ func getStruct(outType reflect.Type, syscall syscall15Args) (v reflect.Value) {
 func placeRegisters(v reflect.Value, addFloat func(uintptr), addInt func(uintptr)) {
 	panic("purego: not needed on other platforms")
 }
+
+func callbackStructArg(ty reflect.Type, numInts, numFloats *int, nextInt, nextFloat, nextStack func() uintptr) reflect.Value {
+	panic("purego: struct arguments are not supported")
+}
+
+func callbackStructResult(v reflect.Value, addInt, addFloat func(uintptr)) {
+	panic("purego: struct returns are not supported")
+}
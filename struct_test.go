@@ -1,7 +1,7 @@
 // SPDX-License-Identifier: Apache-2.0
 // SPDX-FileCopyrightText: 2024 The Ebitengine Authors
 
-//go:build darwin && (arm64 || amd64)
+//go:build darwin || (linux && (amd64 || arm64 || loong64))
 
 package purego_test
 
@@ -499,6 +499,82 @@ func TestRegisterFunc_structArgs(t *testing.T) {
 			t.Fatalf("FourInt32s returned %d wanted %d", result, want)
 		}
 	}
+	{
+		type TwoPointers struct {
+			x, y *int64
+		}
+		var TwoPointersFn func(TwoPointers) int64
+		purego.RegisterLibFunc(&TwoPointersFn, lib, "TwoPointers")
+		x, y := int64(0xdead0000), int64(0xbeef)
+		if ret := TwoPointersFn(TwoPointers{&x, &y}); ret != expectedUnsigned {
+			t.Fatalf("TwoPointers returned %#x wanted %#x", ret, expectedUnsigned)
+		}
+	}
+	{
+		type PointerAndInt struct {
+			x *int64
+			n int32
+		}
+		var PointerAndIntFn func(PointerAndInt) int64
+		purego.RegisterLibFunc(&PointerAndIntFn, lib, "PointerAndInt")
+		x := int64(-41)
+		const want = -41 * 3
+		if ret := PointerAndIntFn(PointerAndInt{&x, 3}); ret != want {
+			t.Fatalf("PointerAndInt returned %d wanted %d", ret, want)
+		}
+	}
+	{
+		type Bools struct {
+			a, b, c bool
+			d       int32
+		}
+		var BoolsFn func(Bools) int32
+		purego.RegisterLibFunc(&BoolsFn, lib, "Bools")
+		if ret := BoolsFn(Bools{true, false, true, 10}); ret != 15 {
+			t.Fatalf("Bools returned %d wanted %d", ret, 15)
+		}
+		if ret := BoolsFn(Bools{false, true, false, -10}); ret != -8 {
+			t.Fatalf("Bools returned %d wanted %d", ret, -8)
+		}
+	}
+	{
+		type Int32Int8Int64 struct {
+			a int32
+			b int8
+			c int64
+		}
+		var Int32Int8Int64Fn func(Int32Int8Int64) int64
+		purego.RegisterLibFunc(&Int32Int8Int64Fn, lib, "Int32Int8Int64")
+		const want = 100_000 - 12 + 0xdeadbeef
+		if ret := Int32Int8Int64Fn(Int32Int8Int64{100_000, -12, 0xdeadbeef}); ret != want {
+			t.Fatalf("Int32Int8Int64 returned %d wanted %d", ret, want)
+		}
+	}
+	{
+		type FiveInt32s struct {
+			a, b, c, d, e int32
+		}
+		var FiveInt32sFn func(FiveInt32s) int32
+		purego.RegisterLibFunc(&FiveInt32sFn, lib, "FiveInt32s")
+		const want = 1 + 20 + 300 + 4000 + 50000
+		if ret := FiveInt32sFn(FiveInt32s{1, 20, 300, 4000, 50000}); ret != want {
+			t.Fatalf("FiveInt32s returned %d wanted %d", ret, want)
+		}
+	}
+	{
+		type LongsAndBool struct {
+			a, b int64
+			c    bool
+		}
+		var LongsAndBoolFn func(LongsAndBool) int64
+		purego.RegisterLibFunc(&LongsAndBoolFn, lib, "LongsAndBool")
+		if ret := LongsAndBoolFn(LongsAndBool{0xdead0000, 0xbeef, true}); ret != expectedUnsigned {
+			t.Fatalf("LongsAndBool returned %#x wanted %#x", ret, expectedUnsigned)
+		}
+		if ret := LongsAndBoolFn(LongsAndBool{0xdead0000, 0xbeef, false}); ret != -1 {
+			t.Fatalf("LongsAndBool returned %d wanted %d", ret, -1)
+		}
+	}
 }
 
 func TestRegisterFunc_structReturns(t *testing.T) {
@@ -772,4 +848,42 @@ func TestRegisterFunc_structReturns(t *testing.T) {
 		runtime.KeepAlive(a)
 		runtime.KeepAlive(b)
 	}
+	{
+		type Bools struct {
+			a, b bool
+			c    int32
+		}
+		var ReturnBools func(a, b bool, c int32) Bools
+		purego.RegisterLibFunc(&ReturnBools, lib, "ReturnBools")
+		expected := Bools{true, false, -7}
+		if ret := ReturnBools(true, false, -7); ret != expected {
+			t.Fatalf("ReturnBools returned %+v wanted %+v", ret, expected)
+		}
+	}
+	{
+		type PtrAndBool struct {
+			p  unsafe.Pointer
+			ok bool
+		}
+		var ReturnPtrAndBool func(p unsafe.Pointer, ok bool) PtrAndBool
+		purego.RegisterLibFunc(&ReturnPtrAndBool, lib, "ReturnPtrAndBool")
+		p := new(int64)
+		expected := PtrAndBool{unsafe.Pointer(p), true}
+		if ret := ReturnPtrAndBool(unsafe.Pointer(p), true); ret != expected {
+			t.Fatalf("ReturnPtrAndBool returned %+v wanted %+v", ret, expected)
+		}
+		runtime.KeepAlive(p)
+	}
+	{
+		type LongsAndBool struct {
+			a, b int64
+			c    bool
+		}
+		var ReturnLongsAndBool func(a, b int64, c bool) LongsAndBool
+		purego.RegisterLibFunc(&ReturnLongsAndBool, lib, "ReturnLongsAndBool")
+		expected := LongsAndBool{-1, 0xcafebabe, true}
+		if ret := ReturnLongsAndBool(-1, 0xcafebabe, true); ret != expected {
+			t.Fatalf("ReturnLongsAndBool returned %+v wanted %+v", ret, expected)
+		}
+	}
 }
@@ -8,13 +8,18 @@
 #include "go_asm.h"
 #include "funcdata.h"
 
-#define STACK_SIZE 80
-#define PTR_ADDRESS (STACK_SIZE - 8)
+#define STACK_SIZE 16
+#define PTR_ADDRESS -8
 
 // syscall15X calls a function in libc on behalf of the syscall package.
 // syscall15X takes a pointer to a struct like:
 // struct {
 //	fn    uintptr
+//	f1-f8 uintptr
+//	arm64_r8 uintptr
+//	errnoFn uintptr
+//	err   uintptr
+//	nstack uintptr
 //	a1    uintptr
 //	a2    uintptr
 //	a3    uintptr
@@ -30,10 +35,9 @@
 //	a13    uintptr
 //	a14    uintptr
 //	a15    uintptr
-//	r1    uintptr
-//	r2    uintptr
-//	err   uintptr
+//	a16   [maxArgs-15]uintptr
 // }
+// The nstack arguments after a6 are copied onto the stack.
 // syscall15X must be called on the g0 stack with the
 // C calling convention (use libcCall).
 GLOBL ·syscall15XABI0(SB), NOPTR|RODATA, $8
@@ -43,7 +47,28 @@ TEXT syscall15X(SB), NOSPLIT|NOFRAME, $0
 	MOVQ  SP, BP
 	SUBQ  $STACK_SIZE, SP
 	MOVQ  DI, PTR_ADDRESS(BP) // save the pointer
-	MOVQ  DI, R11
+
+	// clear errno so that only errors from fn are reported
+	MOVQ syscall15Args_errnoFn(DI), R10
+	TESTQ R10, R10
+	JZ   noerrno
+	CALL R10
+	MOVL $0, (AX)
+
+noerrno:
+	MOVQ PTR_ADDRESS(BP), R11
+
+	// make room for the stack arguments keeping SP 16 byte aligned
+	MOVQ syscall15Args_nstack(R11), CX
+	LEAQ 1(CX), AX
+	ANDQ $~1, AX
+	SHLQ $3, AX
+	SUBQ AX, SP
+
+	// copy the remaining parameters onto the stack
+	LEAQ syscall15Args_a7(R11), SI
+	MOVQ SP, DI
+	REP; MOVSQ
 
 	MOVQ syscall15Args_f1(R11), X0 // f1
 	MOVQ syscall15Args_f2(R11), X1 // f2
@@ -60,43 +85,40 @@ TEXT syscall15X(SB), NOSPLIT|NOFRAME, $0
 	MOVQ syscall15Args_a4(R11), CX // a4
 	MOVQ syscall15Args_a5(R11), R8 // a5
 	MOVQ syscall15Args_a6(R11), R9 // a6
-
-	// push the remaining paramters onto the stack
-	MOVQ syscall15Args_a7(R11), R12
-	MOVQ R12, 0(SP)                  // push a7
-	MOVQ syscall15Args_a8(R11), R12
-	MOVQ R12, 8(SP)                  // push a8
-	MOVQ syscall15Args_a9(R11), R12
-	MOVQ R12, 16(SP)                 // push a9
-	MOVQ syscall15Args_a10(R11), R12
-	MOVQ R12, 24(SP)                 // push a10
-	MOVQ syscall15Args_a11(R11), R12
-	MOVQ R12, 32(SP)                 // push a11
-	MOVQ syscall15Args_a12(R11), R12
-	MOVQ R12, 40(SP)                 // push a12
-	MOVQ syscall15Args_a13(R11), R12
-	MOVQ R12, 48(SP)                 // push a13
-	MOVQ syscall15Args_a14(R11), R12
-	MOVQ R12, 56(SP)                 // push a14
-	MOVQ syscall15Args_a15(R11), R12
-	MOVQ R12, 64(SP)                 // push a15
-	XORL AX, AX                      // vararg: say "no float args"
+	MOVL $8, AX                    // vararg: upper bound of the vector registers used
 
 	MOVQ syscall15Args_fn(R11), R10 // fn
 	CALL R10
 
+	// remove the stack arguments
+	LEAQ -STACK_SIZE(BP), SP
+
 	MOVQ PTR_ADDRESS(BP), DI      // get the pointer back
 	MOVQ AX, syscall15Args_a1(DI) // r1
 	MOVQ DX, syscall15Args_a2(DI) // r3
 	MOVQ X0, syscall15Args_f1(DI) // f1
 	MOVQ X1, syscall15Args_f2(DI) // f2
 
+	// read errno on the same thread fn was called on
+	MOVQ syscall15Args_errnoFn(DI), R10
+	TESTQ R10, R10
+	JZ   done
+	CALL R10
+	MOVLQSX (AX), AX
+	MOVQ PTR_ADDRESS(BP), DI
+	MOVQ AX, syscall15Args_err(DI) // err
+
+done:
 	XORL AX, AX          // no error (it's ignored anyway)
-	ADDQ $STACK_SIZE, SP
 	MOVQ BP, SP
 	POPQ BP
 	RET
 
+#ifdef GOOS_linux
+GLOBL ·callbackasm1ABI0(SB), NOPTR|RODATA, $8
+DATA ·callbackasm1ABI0(SB)/8, $callbackasm1(SB)
+#endif
+
 TEXT callbackasm1(SB), NOSPLIT|NOFRAME, $0
 	MOVQ 0(SP), AX  // save the return address to calculate the cb index
 	MOVQ 8(SP), R10 // get the return SP so that we can align register args with stack args
@@ -135,12 +157,13 @@ TEXT callbackasm1(SB), NOSPLIT|NOFRAME, $0
 
 	// Create a struct callbackArgs on our stack to be passed as
 	// the "frame" to cgocallback and on to callbackWrap.
-	// $24 to make enough room for the arguments to runtime.cgocallback
-	SUBQ $(24+callbackArgs__size), SP
-	MOVQ AX, (24+callbackArgs_index)(SP)  // callback index
-	MOVQ R8, (24+callbackArgs_args)(SP)   // address of args vector
-	MOVQ $0, (24+callbackArgs_result)(SP) // result
-	LEAQ 24(SP), AX                       // take the address of callbackArgs
+	// $32 to make enough room for the arguments to runtime.cgocallback
+	// and keep SP 16 byte aligned
+	SUBQ $(32+callbackArgs__size), SP
+	MOVQ AX, (32+callbackArgs_index)(SP)  // callback index
+	MOVQ R8, (32+callbackArgs_args)(SP)   // address of args vector
+	MOVQ $0, (32+callbackArgs_result)(SP) // result
+	LEAQ 32(SP), AX                       // take the address of callbackArgs
 
 	// Call cgocallback, which will call callbackWrap(frame).
 	MOVQ ·callbackWrap_call(SB), DI // Get the ABIInternal function pointer
@@ -151,8 +174,11 @@ TEXT callbackasm1(SB), NOSPLIT|NOFRAME, $0
 	CALL crosscall2(SB) // runtime.cgocallback(fn, frame, ctxt uintptr)
 
 	// Get callback result.
-	MOVQ (24+callbackArgs_result)(SP), AX
-	ADDQ $(24+callbackArgs__size), SP     // remove callbackArgs struct
+	MOVQ  (32+callbackArgs_result)(SP), AX
+	MOVQ  (32+callbackArgs_result2)(SP), DX
+	MOVSD (32+callbackArgs_fresult+0*8)(SP), X0
+	MOVSD (32+callbackArgs_fresult+1*8)(SP), X1
+	ADDQ $(32+callbackArgs__size), SP     // remove callbackArgs struct
 
 	POP_REGS_HOST_TO_ABI0()
 
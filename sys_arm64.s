@@ -7,13 +7,19 @@
 #include "go_asm.h"
 #include "funcdata.h"
 
-#define STACK_SIZE 64
-#define PTR_ADDRESS (STACK_SIZE - 8)
+#define STACK_SIZE 16
+#define PTR_ADDRESS 0
+#define R19_ADDRESS 8
 
 // syscall15X calls a function in libc on behalf of the syscall package.
 // syscall15X takes a pointer to a struct like:
 // struct {
 //	fn    uintptr
+//	f1-f8 uintptr
+//	arm64_r8 uintptr
+//	errnoFn uintptr
+//	err   uintptr
+//	nstack uintptr
 //	a1    uintptr
 //	a2    uintptr
 //	a3    uintptr
@@ -29,10 +35,9 @@
 //	a13    uintptr
 //	a14    uintptr
 //	a15    uintptr
-//	r1    uintptr
-//	r2    uintptr
-//	err   uintptr
+//	a16   [maxArgs-15]uintptr
 // }
+// The nstack arguments after a8 are copied onto the stack.
 // syscall15X must be called on the g0 stack with the
 // C calling convention (use libcCall).
 GLOBL ·syscall15XABI0(SB), NOPTR|RODATA, $8
@@ -40,8 +45,38 @@ DATA ·syscall15XABI0(SB)/8, $syscall15X(SB)
 TEXT syscall15X(SB), NOSPLIT, $0
 	SUB  $STACK_SIZE, RSP     // push structure pointer
 	MOVD R0, PTR_ADDRESS(RSP)
+	MOVD R19, R19_ADDRESS(RSP) // R19 is callee-saved and holds RSP during the call
 	MOVD R0, R9
 
+	// clear errno so that only errors from fn are reported
+	MOVD syscall15Args_errnoFn(R9), R10
+	CBZ  R10, noerrno
+	BL   (R10)
+	MOVW ZR, (R0)
+	MOVD PTR_ADDRESS(RSP), R9
+
+noerrno:
+	// make room for the stack arguments keeping RSP 16 byte aligned
+	MOVD RSP, R19
+	MOVD syscall15Args_nstack(R9), R11
+	ADD  $1, R11, R12
+	AND  $~1, R12
+	LSL  $3, R12
+	MOVD RSP, R13
+	SUB  R12, R13
+	MOVD R13, RSP
+
+	// copy the remaining parameters onto the stack
+	ADD  $syscall15Args_a9, R9, R12
+	CBZ  R11, copied
+
+copy:
+	MOVD.P 8(R12), R10
+	MOVD.P R10, 8(R13)
+	SUB    $1, R11
+	CBNZ   R11, copy
+
+copied:
 	FMOVD syscall15Args_f1(R9), F0 // f1
 	FMOVD syscall15Args_f2(R9), F1 // f2
 	FMOVD syscall15Args_f3(R9), F2 // f3
@@ -61,26 +96,11 @@ TEXT syscall15X(SB), NOSPLIT, $0
 	MOVD syscall15Args_a8(R9), R7       // a8
 	MOVD syscall15Args_arm64_r8(R9), R8 // r8
 
-	MOVD syscall15Args_a9(R9), R10
-	MOVD R10, 0(RSP)                // push a9 onto stack
-	MOVD syscall15Args_a10(R9), R10
-	MOVD R10, 8(RSP)                // push a10 onto stack
-	MOVD syscall15Args_a11(R9), R10
-	MOVD R10, 16(RSP)               // push a11 onto stack
-	MOVD syscall15Args_a12(R9), R10
-	MOVD R10, 24(RSP)               // push a12 onto stack
-	MOVD syscall15Args_a13(R9), R10
-	MOVD R10, 32(RSP)               // push a13 onto stack
-	MOVD syscall15Args_a14(R9), R10
-	MOVD R10, 40(RSP)               // push a14 onto stack
-	MOVD syscall15Args_a15(R9), R10
-	MOVD R10, 48(RSP)               // push a15 onto stack
-
 	MOVD syscall15Args_fn(R9), R10 // fn
 	BL   (R10)
 
-	MOVD PTR_ADDRESS(RSP), R2 // pop structure pointer
-	ADD  $STACK_SIZE, RSP
+	MOVD R19, RSP             // remove the stack arguments
+	MOVD PTR_ADDRESS(RSP), R2 // get structure pointer
 
 	MOVD  R0, syscall15Args_a1(R2) // save r1
 	MOVD  R1, syscall15Args_a2(R2) // save r3
@@ -89,4 +109,15 @@ TEXT syscall15X(SB), NOSPLIT, $0
 	FMOVD F2, syscall15Args_f3(R2) // save f2
 	FMOVD F3, syscall15Args_f4(R2) // save f3
 
+	// read errno on the same thread fn was called on
+	MOVD syscall15Args_errnoFn(R2), R10
+	CBZ  R10, done
+	BL   (R10)
+	MOVW (R0), R0
+	MOVD PTR_ADDRESS(RSP), R2
+	MOVD R0, syscall15Args_err(R2) // save err
+
+done:
+	MOVD R19_ADDRESS(RSP), R19
+	ADD  $STACK_SIZE, RSP // pop structure pointer
 	RET
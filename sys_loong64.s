@@ -7,13 +7,19 @@
 #include "go_asm.h"
 #include "funcdata.h"
 
-#define STACK_SIZE 64
-#define PTR_ADDRESS (STACK_SIZE - 8)
+#define STACK_SIZE 16
+#define PTR_ADDRESS 0
+#define R23_ADDRESS 8
 
 // syscall15X calls a function in libc on behalf of the syscall package.
 // syscall15X takes a pointer to a struct like:
 // struct {
 //	fn    uintptr
+//	f1-f8 uintptr
+//	arm64_r8 uintptr
+//	errnoFn uintptr
+//	err   uintptr
+//	nstack uintptr
 //	a1    uintptr
 //	a2    uintptr
 //	a3    uintptr
@@ -29,10 +35,9 @@
 //	a13    uintptr
 //	a14    uintptr
 //	a15    uintptr
-//	r1    uintptr
-//	r2    uintptr
-//	err   uintptr
+//	a16   [maxArgs-15]uintptr
 // }
+// The nstack arguments after a8 are copied onto the stack.
 // syscall15X must be called on the g0 stack with the
 // C calling convention (use libcCall).
 GLOBL ·syscall15XABI0(SB), NOPTR|RODATA, $8
@@ -41,8 +46,38 @@ TEXT syscall15X(SB), NOSPLIT, $0
 	// push structure pointer
 	SUBV	$STACK_SIZE, R3
 	MOVV	R4, PTR_ADDRESS(R3)
+	MOVV	R23, R23_ADDRESS(R3)	// R23 is callee-saved and holds R3 during the call
 	MOVV	R4, R13
 
+	// clear errno so that only errors from fn are reported
+	MOVV	syscall15Args_errnoFn(R13), R12
+	BEQ	R12, noerrno
+	JAL	(R12)
+	MOVW	R0, (R4)
+	MOVV	PTR_ADDRESS(R3), R13
+
+noerrno:
+	// make room for the stack arguments keeping R3 16 byte aligned
+	MOVV	R3, R23
+	MOVV	syscall15Args_nstack(R13), R14
+	SLLV	$3, R14, R15
+	SUBV	R15, R3
+	AND	$~15, R3
+
+	// copy the remaining parameters onto the stack
+	ADDV	$syscall15Args_a9, R13, R15
+	MOVV	R3, R16
+	BEQ	R14, copied
+
+copy:
+	MOVV	0(R15), R12
+	MOVV	R12, 0(R16)
+	ADDV	$8, R15
+	ADDV	$8, R16
+	SUBV	$1, R14
+	BNE	R14, copy
+
+copied:
 	MOVD	syscall15Args_f1(R13), F0	// f1
 	MOVD	syscall15Args_f2(R13), F1	// f2
 	MOVD	syscall15Args_f3(R13), F2	// f3
@@ -61,28 +96,12 @@ TEXT syscall15X(SB), NOSPLIT, $0
 	MOVV	syscall15Args_a7(R13), R10	// a7
 	MOVV	syscall15Args_a8(R13), R11	// a8
 
-	// push a9-a15 onto stack
-	MOVV	syscall15Args_a9(R13), R12
-	MOVV	R12, 0(R3)
-	MOVV	syscall15Args_a10(R13), R12
-	MOVV	R12, 8(R3)
-	MOVV	syscall15Args_a11(R13), R12
-	MOVV	R12, 16(R3)
-	MOVV	syscall15Args_a12(R13), R12
-	MOVV	R12, 24(R3)
-	MOVV	syscall15Args_a13(R13), R12
-	MOVV	R12, 32(R3)
-	MOVV	syscall15Args_a14(R13), R12
-	MOVV	R12, 40(R3)
-	MOVV	syscall15Args_a15(R13), R12
-	MOVV	R12, 48(R3)
-
 	MOVV	syscall15Args_fn(R13), R12
 	JAL	(R12)
 
-	// pop structure pointer
+	// remove the stack arguments and get structure pointer
+	MOVV	R23, R3
 	MOVV	PTR_ADDRESS(R3), R13
-	ADDV	$STACK_SIZE, R3
 
 	// save R4, R5
 	MOVV	R4, syscall15Args_a1(R13)
@@ -93,4 +112,17 @@ TEXT syscall15X(SB), NOSPLIT, $0
 	MOVD	F1, syscall15Args_f2(R13)
 	MOVD	F2, syscall15Args_f3(R13)
 	MOVD	F3, syscall15Args_f4(R13)
+
+	// read errno on the same thread fn was called on
+	MOVV	syscall15Args_errnoFn(R13), R12
+	BEQ	R12, done
+	JAL	(R12)
+	MOVW	(R4), R4
+	MOVV	PTR_ADDRESS(R3), R13
+	MOVV	R4, syscall15Args_err(R13)
+
+done:
+	// pop structure pointer
+	MOVV	R23_ADDRESS(R3), R23
+	ADDV	$STACK_SIZE, R3
 	RET
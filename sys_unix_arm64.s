@@ -8,6 +8,11 @@
 #include "funcdata.h"
 #include "abi_arm64.h"
 
+#ifdef GOOS_linux
+GLOBL ·callbackasm1ABI0(SB), NOPTR|RODATA, $8
+DATA ·callbackasm1ABI0(SB)/8, $callbackasm1(SB)
+#endif
+
 TEXT callbackasm1(SB), NOSPLIT|NOFRAME, $0
 	NO_LOCAL_POINTERS
 
@@ -43,11 +48,12 @@ TEXT callbackasm1(SB), NOSPLIT|NOFRAME, $0
 	// so it's saved here.
 	STP (R27, R30), 0(RSP)
 
-	// Create a struct callbackArgs on our stack.
-	MOVD $(callbackArgs__size)(RSP), R13
+	// Create a struct callbackArgs on our stack between the saved registers
+	// and the register arguments which start at (10*8)(RSP).
+	MOVD $16(RSP), R13
 	MOVD R12, callbackArgs_index(R13)    // callback index
 	MOVD R14, callbackArgs_args(R13)     // address of args vector
-	MOVD ZR, callbackArgs_result(R13)    // result
+	MOVD R8, callbackArgs_result(R13)    // result starts as the address for a struct result
 
 	// Move parameters into registers
 	// Get the ABIInternal function pointer
@@ -60,8 +66,13 @@ TEXT callbackasm1(SB), NOSPLIT|NOFRAME, $0
 	BL crosscall2(SB)
 
 	// Get callback result.
-	MOVD $(callbackArgs__size)(RSP), R13
-	MOVD callbackArgs_result(R13), R0
+	MOVD $16(RSP), R13
+	MOVD  callbackArgs_result(R13), R0
+	MOVD  callbackArgs_result2(R13), R1
+	FMOVD (callbackArgs_fresult+0*8)(R13), F0
+	FMOVD (callbackArgs_fresult+1*8)(R13), F1
+	FMOVD (callbackArgs_fresult+2*8)(R13), F2
+	FMOVD (callbackArgs_fresult+3*8)(R13), F3
 
 	// Restore LR and R27
 	LDP 0(RSP), (R27, R30)
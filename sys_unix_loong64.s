@@ -30,7 +30,7 @@ TEXT callbackasm1(SB), NOSPLIT|NOFRAME, $0
 	MOVV	R11, 120(R14)
 
 	// Adjust SP by frame size.
-	SUBV	$(22*8), R3
+	SUBV	$(26*8), R3
 
 	// It is important to save R30 because the go assembler
 	// uses it for move instructions for a variable.
@@ -47,8 +47,9 @@ TEXT callbackasm1(SB), NOSPLIT|NOFRAME, $0
 	MOVV	R1, 0(R3)
 	MOVV	R30, 8(R3)
 
-	// Create a struct callbackArgs on our stack.
-	MOVV	$(callbackArgs__size)(R3), R13
+	// Create a struct callbackArgs on our stack between the saved registers
+	// and the register arguments which start at (10*8)(R3).
+	MOVV	$16(R3), R13
 	MOVV	R12, callbackArgs_index(R13)    // callback index
 	MOVV	R14, callbackArgs_args(R13)     // address of args vector
 	MOVV	$0, callbackArgs_result(R13)    // result
@@ -64,12 +65,17 @@ TEXT callbackasm1(SB), NOSPLIT|NOFRAME, $0
 	JAL	crosscall2(SB)
 
 	// Get callback result.
-	MOVV	$(callbackArgs__size)(R3), R13
+	MOVV	$16(R3), R13
 	MOVV	callbackArgs_result(R13), R4
+	MOVV	callbackArgs_result2(R13), R5
+	MOVD	(callbackArgs_fresult+0*8)(R13), F0
+	MOVD	(callbackArgs_fresult+1*8)(R13), F1
+	MOVD	(callbackArgs_fresult+2*8)(R13), F2
+	MOVD	(callbackArgs_fresult+3*8)(R13), F3
 
 	// Restore LR and R30
 	MOVV	0(R3), R1
 	MOVV	8(R3), R30
-	ADDV	$(22*8), R3
+	ADDV	$(26*8), R3
 
 	RET
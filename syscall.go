@@ -5,6 +5,12 @@
 
 package purego
 
+import (
+	"reflect"
+	"syscall"
+	"unsafe"
+)
+
 // CDecl marks a function as being called using the __cdecl calling convention as defined in
 // the [MSDocs] when passed to NewCallback. It must be the first argument to the function.
 // This is only useful on 386 Windows, but it is safe to use on other platforms.
@@ -12,20 +18,85 @@ package purego
 // [MSDocs]: https://learn.microsoft.com/en-us/cpp/cpp/cdecl?view=msvc-170
 type CDecl struct{}
 
+// Variadic marks where the variable arguments of a C variadic function such as printf start
+// when it is a parameter of a function passed to RegisterFunc. It takes no space in the call.
+// See RegisterFunc for details.
+type Variadic struct{}
+
+var variadicType = reflect.TypeOf(Variadic{})
+
 const (
-	maxArgs             = 15
+	// maxArgs is the maximum number of machine words of arguments passed to a C function.
+	// It matches the limit of syscall.SyscallN on Windows.
+	maxArgs             = 42
 	numOfFloatRegisters = 8 // arm64 and amd64 both have 8 float registers
 )
 
 type syscall15Args struct {
-	fn, a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14, a15 uintptr
-	f1, f2, f3, f4, f5, f6, f7, f8                                       uintptr
-	arm64_r8                                                             uintptr
+	fn                             uintptr
+	f1, f2, f3, f4, f5, f6, f7, f8 uintptr
+	arm64_r8                       uintptr
+	errnoFn                        uintptr // returns the address of errno or 0 to not capture it
+	err                            uintptr // errno after the call if errnoFn is set
+	nstack                         uintptr // the number of arguments after the integer registers placed on the stack
+
+	a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14, a15 uintptr
+	a16                                                              [maxArgs - 15]uintptr // the arguments after a15
+}
+
+// ints returns a1 through a15 and a16 as an array. The first numOfIntegerRegisters are
+// placed in registers and the following nstack on the stack.
+func (s *syscall15Args) ints() *[maxArgs]uintptr {
+	return (*[maxArgs]uintptr)(unsafe.Pointer(&s.a1))
+}
+
+// floats returns f1 through f8 as an array.
+func (s *syscall15Args) floats() *[numOfFloatRegisters]uintptr {
+	return (*[numOfFloatRegisters]uintptr)(unsafe.Pointer(&s.f1))
+}
+
+var (
+	errnoType = reflect.TypeOf(syscall.Errno(0))
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// hasErrnoResult reports whether the last result of the function type ty receives errno.
+// A single result of type syscall.Errno is the return value of the C function
+// so only a single error or a second syscall.Errno or error result receives errno.
+func hasErrnoResult(ty reflect.Type) bool {
+	switch ty.NumOut() {
+	case 1:
+		return ty.Out(0) == errorType
+	case 2:
+		return ty.Out(1) == errnoType || ty.Out(1) == errorType
+	}
+	return false
+}
+
+// returnType returns the type of the result of ty that receives the return value of the C function
+// or nil if there is none.
+func returnType(ty reflect.Type) reflect.Type {
+	if ty.NumOut() == 0 || ty.NumOut() == 1 && hasErrnoResult(ty) {
+		return nil
+	}
+	return ty.Out(0)
+}
+
+// errnoValue converts errno to ty which is either syscall.Errno or error.
+// The error is nil if errno is zero.
+func errnoValue(ty reflect.Type, errno uintptr) reflect.Value {
+	if ty == errnoType {
+		return reflect.ValueOf(syscall.Errno(errno))
+	}
+	if errno == 0 {
+		return reflect.Zero(ty)
+	}
+	return reflect.ValueOf(syscall.Errno(errno)).Convert(ty)
 }
 
 // SyscallN takes fn, a C function pointer and a list of arguments as uintptr.
-// There is an internal maximum number of arguments that SyscallN can take. It panics
-// when the maximum is exceeded. It returns the result and the libc error code if there is one.
+// SyscallN can take up to 42 arguments. It panics when the maximum is exceeded. It returns the result and the libc error code if there is one.
+// errno is set to zero before fn is called and read afterward on the same thread.
 //
 // In order to call this function properly make sure to follow all the rules specified in [unsafe.Pointer]
 // especially point 4.
@@ -49,8 +120,5 @@ func SyscallN(fn uintptr, args ...uintptr) (r1, r2, err uintptr) {
 	if len(args) > maxArgs {
 		panic("purego: too many arguments to SyscallN")
 	}
-	// add padding so there is no out-of-bounds slicing
-	var tmp [maxArgs]uintptr
-	copy(tmp[:], args)
-	return syscall_syscall15X(fn, tmp[0], tmp[1], tmp[2], tmp[3], tmp[4], tmp[5], tmp[6], tmp[7], tmp[8], tmp[9], tmp[10], tmp[11], tmp[12], tmp[13], tmp[14])
+	return syscall_syscallN(fn, args)
 }
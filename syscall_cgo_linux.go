@@ -11,11 +11,18 @@ import (
 
 var syscall15XABI0 = uintptr(cgo.Syscall15XABI0)
 
+// errnoLocation is zero because the C version of syscall15X always captures errno.
+var errnoLocation uintptr
+
 //go:nosplit
-func syscall_syscall15X(fn, a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14, a15 uintptr) (r1, r2, err uintptr) {
-	return cgo.Syscall15X(fn, a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14, a15)
+func syscall_syscallN(fn uintptr, args []uintptr) (r1, r2, err uintptr) {
+	return cgo.SyscallN(fn, args)
 }
 
 func NewCallback(_ any) uintptr {
 	panic("purego: NewCallback on Linux is only supported on amd64/arm64/loong64")
 }
+
+func FreeCallback(_ uintptr) {
+	panic("purego: FreeCallback on Linux is only supported on amd64/arm64/loong64")
+}
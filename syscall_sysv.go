@@ -6,34 +6,72 @@
 package purego
 
 import (
+	"math"
 	"reflect"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"unsafe"
 )
 
 var syscall15XABI0 uintptr
 
-func syscall_syscall15X(fn, a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14, a15 uintptr) (r1, r2, err uintptr) {
-	args := thePool.Get().(*syscall15Args)
-	defer thePool.Put(args)
+func syscall_syscallN(fn uintptr, args []uintptr) (r1, r2, err uintptr) {
+	syscall := thePool.Get().(*syscall15Args)
+	defer thePool.Put(syscall)
 
-	*args = syscall15Args{
-		fn, a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14, a15,
-		a1, a2, a3, a4, a5, a6, a7, a8,
-		0,
+	*syscall = syscall15Args{fn: fn, errnoFn: errnoLocation}
+	// the arguments are passed in both the integer and float registers
+	copy(syscall.ints()[:], args)
+	copy(syscall.floats()[:], args)
+	if n := len(args) - numOfIntegerRegisters(); n > 0 {
+		syscall.nstack = uintptr(n)
 	}
 
-	runtime_cgocall(syscall15XABI0, unsafe.Pointer(args))
-	return args.a1, args.a2, 0
+	runtime_cgocall(syscall15XABI0, unsafe.Pointer(syscall))
+	return syscall.a1, syscall.a2, syscall.err
 }
 
+// errnoLocation is the address of the C function that returns a pointer to
+// the errno of the current thread. The trampoline calls it right before and
+// after the C function so errno is read on the same thread. It is zero if the
+// function couldn't be found in which case errno is always reported as zero.
+var errnoLocation uintptr
+
+func init() {
+	name := "__errno_location" // glibc and musl
+	switch runtime.GOOS {
+	case "darwin", "freebsd":
+		name = "__error"
+	case "android", "netbsd":
+		name = "__errno"
+	}
+	errnoLocation, _ = Dlsym(RTLD_DEFAULT, name)
+	mallocAddr, _ = Dlsym(RTLD_DEFAULT, "malloc")
+}
+
+// mallocAddr is the address of malloc from libc which allocates the strings returned by callbacks.
+var mallocAddr uintptr
+
 // NewCallback converts a Go function to a function pointer conforming to the C calling convention.
 // This is useful when interoperating with C code requiring callbacks. The argument is expected to be a
-// function with zero or one uintptr-sized result. The function must not have arguments with size larger than the size
-// of uintptr. Only a limited number of callbacks may be created in a single Go process, and any memory allocated
-// for these callbacks is never released. At least 2000 callbacks can always be created. Although this function
-// provides similar functionality to windows.NewCallback it is distinct.
+// function with zero or one result which may be uintptr-sized, a float or a struct. The function must not
+// have arguments with size larger than the size of uintptr except for complex numbers and structs. Structs
+// are passed and returned by value like RegisterFunc does on the platforms that support it. Although this
+// function provides similar functionality to windows.NewCallback it is distinct.
+//
+// At least 2000 callbacks can always be created. On Linux amd64 and arm64, more are created by mapping
+// executable memory at runtime.
+//
+// A parameter of type Handle receives a Handle passed to C as void *userdata. A parameter of an interface
+// type like any receives the Value of that Handle which must implement the interface.
+//
+// A string parameter is copied from a NUL-terminated char *. A func parameter wraps a C function pointer
+// like RegisterFunc does and is reused while the callback lives. A NULL function pointer is a nil func.
+// A string result is copied into memory allocated with malloc from libc which the C caller owns and must
+// release with free.
+//
+// Callbacks that are no longer used by C can be released with FreeCallback so their slot is reused.
 func NewCallback(fn any) uintptr {
 	ty := reflect.TypeOf(fn)
 	for i := 0; i < ty.NumIn(); i++ {
@@ -48,14 +86,84 @@ func NewCallback(fn any) uintptr {
 	return compileCallback(fn)
 }
 
-// maxCb is the maximum number of callbacks
+// FreeCallback releases a callback returned by NewCallback so that a later call to NewCallback can reuse it.
+// C code must not call cb after it has been released. FreeCallback panics if cb isn't a live callback.
+func FreeCallback(cb uintptr) {
+	cbs.lock.Lock()
+	defer cbs.lock.Unlock()
+	i := callbackIndex(cb)
+	if i < 0 || i >= cbs.n || loadCallback(i) == nil {
+		panic("purego: FreeCallback called with an invalid callback")
+	}
+	storeCallback(i, nil)
+	cbs.free = append(cbs.free, i)
+}
+
+// SetCallbackPanicHandler sets the function that handles a panic in a callback, whether it comes
+// from the Go function or from converting its arguments or result. By default a panic unwinds
+// through the C frames that called the callback which usually crashes the process. Once a handler
+// is set, the panic is recovered instead and h is called with the address of the callback and the
+// value passed to panic. The value h returns is converted to the result type of the callback and
+// returned to C. If it returns nil or a value that can't be converted, the zero value is returned.
+// Passing nil restores the default behavior.
+func SetCallbackPanicHandler(h func(cb uintptr, v any) any) {
+	callbackPanicHandler.Store(callbackPanic{h})
+}
+
+// callbackPanicHandler holds a callbackPanic since atomic.Value can't store nil.
+var callbackPanicHandler atomic.Value
+
+type callbackPanic struct {
+	handler func(cb uintptr, v any) any
+}
+
+// maxCB is the number of callbacks in the callbackasm function
 // only increase this if you have added more to the callbackasm function
 const maxCB = 2000
 
+// callbackTrampolineSize is the size of each trampoline created at runtime
+// for the callbacks past maxCB.
+const callbackTrampolineSize = 32
+
 var cbs struct {
-	lock  sync.Mutex
-	numFn int                  // the number of functions currently in cbs.funcs
-	funcs [maxCB]reflect.Value // the saved callbacks
+	lock  sync.Mutex // serializes NewCallback and FreeCallback
+	n     int        // the number of callback indices handed out
+	free  []int      // the indices of freed callbacks
+	pages []uintptr  // the pages of trampolines for the callbacks from maxCB on
+
+	// chunks is a *[]*callbackChunk holding the callbacks. It is only ever replaced by
+	// a longer copy so callbackWrap can read it and its entries without taking lock.
+	chunks unsafe.Pointer
+}
+
+const callbackChunkSize = 256
+
+// callbackChunk holds the *callback of consecutive indices. A freed callback is nil.
+type callbackChunk [callbackChunkSize]unsafe.Pointer
+
+// loadCallback returns the callback at index i or nil.
+func loadCallback(i int) *callback {
+	chunks := (*[]*callbackChunk)(atomic.LoadPointer(&cbs.chunks))
+	if chunks == nil || i/callbackChunkSize >= len(*chunks) {
+		return nil
+	}
+	return (*callback)(atomic.LoadPointer(&(*chunks)[i/callbackChunkSize][i%callbackChunkSize]))
+}
+
+// storeCallback sets the callback at index i and grows the table if needed.
+// cbs.lock must be held.
+func storeCallback(i int, c *callback) {
+	chunks := (*[]*callbackChunk)(cbs.chunks)
+	if chunks == nil || i/callbackChunkSize >= len(*chunks) {
+		var grown []*callbackChunk
+		if chunks != nil {
+			grown = append(grown, *chunks...)
+		}
+		grown = append(grown, new(callbackChunk))
+		chunks = &grown
+		atomic.StorePointer(&cbs.chunks, unsafe.Pointer(chunks))
+	}
+	atomic.StorePointer(&(*chunks)[i/callbackChunkSize][i%callbackChunkSize], unsafe.Pointer(c))
 }
 
 type callbackArgs struct {
@@ -71,7 +179,12 @@ type callbackArgs struct {
 	// for this callback.
 	args unsafe.Pointer
 	// Below are out-args from callbackWrap
-	result uintptr
+	//
+	// On arm64, result starts as R8 which holds the address for
+	// struct results that don't fit in registers.
+	result  uintptr    // the first integer result register
+	result2 uintptr    // the second integer result register
+	fresult [4]uintptr // the float result registers
 }
 
 func compileCallback(fn any) uintptr {
@@ -90,39 +203,107 @@ func compileCallback(fn any) uintptr {
 			if i == 0 && in.AssignableTo(reflect.TypeOf(CDecl{})) {
 				continue
 			}
-			fallthrough
-		case reflect.Interface, reflect.Func, reflect.Slice,
-			reflect.Chan, reflect.Complex64, reflect.Complex128,
-			reflect.String, reflect.Map, reflect.Invalid:
+			if !isStructSupported() {
+				panic("purego: struct arguments are only supported on darwin, and linux amd64, arm64 & loong64")
+			}
+			checkCallbackStruct(in)
+		case reflect.Func:
+			if err := CheckSignature(in); err != nil {
+				panic(err)
+			}
+		case reflect.Slice, reflect.Chan, reflect.Map, reflect.Invalid:
 			panic("purego: unsupported argument type: " + in.Kind().String())
 		}
 	}
 output:
 	switch {
 	case ty.NumOut() == 1:
-		switch ty.Out(0).Kind() {
+		switch out := ty.Out(0); out.Kind() {
 		case reflect.Pointer, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
-			reflect.Bool, reflect.UnsafePointer:
+			reflect.Bool, reflect.UnsafePointer, reflect.Float32, reflect.Float64, reflect.String:
+			break output
+		case reflect.Struct, reflect.Complex64, reflect.Complex128:
+			if !isStructSupported() {
+				panic("purego: struct return values are only supported on darwin, and linux amd64, arm64 & loong64")
+			}
+			if out.Kind() == reflect.Struct {
+				checkCallbackStruct(out)
+			}
 			break output
 		}
 		panic("purego: unsupported return type: " + ty.String())
 	case ty.NumOut() > 1:
 		panic("purego: callbacks can only have one return")
 	}
+	c := newCallback(val)
 	cbs.lock.Lock()
 	defer cbs.lock.Unlock()
-	if cbs.numFn >= maxCB {
-		panic("purego: the maximum number of callbacks has been reached")
+	var i int
+	if n := len(cbs.free); n > 0 {
+		i = cbs.free[n-1]
+		cbs.free = cbs.free[:n-1]
+	} else {
+		i = cbs.n
+		if i >= maxCB+len(cbs.pages)*callbackTrampolinesPerPage() {
+			page, err := newCallbackTrampolines(i)
+			if err != nil {
+				panic("purego: the maximum number of callbacks has been reached: " + err.Error())
+			}
+			cbs.pages = append(cbs.pages, page)
+		}
+		cbs.n++
 	}
-	cbs.funcs[cbs.numFn] = val
-	cbs.numFn++
-	return callbackasmAddr(cbs.numFn - 1)
+	c.addr = callbackAddr(i)
+	storeCallback(i, c)
+	return c.addr
 }
 
-const ptrSize = unsafe.Sizeof((*int)(nil))
+// checkCallbackStruct panics if the struct type ty can't be passed to or returned from a callback.
+func checkCallbackStruct(ty reflect.Type) {
+	if err := checkStructFieldsSupported(ty); err != nil {
+		panic("purego: " + err.Error())
+	}
+	if reason := checkLayoutTags(ty); reason != "" {
+		panic("purego: " + reason)
+	}
+}
 
-const callbackMaxFrame = 64 * ptrSize
+// callbackAddr returns the address C calls for the callback at index i.
+// cbs.lock must be held.
+func callbackAddr(i int) uintptr {
+	if i < maxCB {
+		return callbackasmAddr(i)
+	}
+	i -= maxCB
+	n := callbackTrampolinesPerPage()
+	return cbs.pages[i/n] + uintptr(i%n)*callbackTrampolineSize
+}
+
+// callbackIndex returns the index of the callback at address cb or -1 if it isn't one.
+// cbs.lock must be held.
+func callbackIndex(cb uintptr) int {
+	if start, end := callbackasmAddr(0), callbackasmAddr(maxCB); cb >= start && cb < end {
+		entrySize := callbackasmAddr(1) - start
+		if (cb-start)%entrySize != 0 {
+			return -1
+		}
+		return int((cb - start) / entrySize)
+	}
+	n := callbackTrampolinesPerPage()
+	for p, page := range cbs.pages {
+		if cb < page || cb >= page+uintptr(n)*callbackTrampolineSize {
+			continue
+		}
+		if (cb-page)%callbackTrampolineSize != 0 {
+			return -1
+		}
+		return maxCB + p*n + int((cb-page)/callbackTrampolineSize)
+	}
+	return -1
+}
+
+const ptrSize = unsafe.Sizeof((*int)(nil))
 
 // callbackasm is implemented in zcallback_GOOS_GOARCH.s
 //
@@ -139,66 +320,134 @@ var callbackWrap_call = callbackWrap
 // callbackWrap is called by assembly code which determines which Go function to call.
 // This function takes the arguments and passes them to the Go function and returns the result.
 func callbackWrap(a *callbackArgs) {
-	cbs.lock.Lock()
-	fn := cbs.funcs[a.index]
-	cbs.lock.Unlock()
-	fnType := fn.Type()
-	args := make([]reflect.Value, fnType.NumIn())
-	frame := (*[callbackMaxFrame]uintptr)(a.args)
-	var floatsN int // floatsN represents the number of float arguments processed
-	var intsN int   // intsN represents the number of integer arguments processed
-	// stack points to the index into frame of the current stack element.
-	// The stack begins after the float and integer registers.
-	stack := numOfIntegerRegisters() + numOfFloatRegisters
+	c := loadCallback(int(a.index))
+	if c == nil {
+		panic("purego: callback called after FreeCallback")
+	}
+	// result is where a struct result that doesn't fit in registers is written
+	var result unsafe.Pointer
+	switch c.result {
+	case callbackResultHidden:
+		// the caller passes the address as a hidden first argument and expects it to be returned
+		a.result = *(*uintptr)(unsafe.Add(a.args, numOfFloatRegisters*ptrSize))
+		result = *(*unsafe.Pointer)(unsafe.Pointer(&a.result))
+	case callbackResultR8:
+		result = *(*unsafe.Pointer)(unsafe.Pointer(&a.result))
+	}
+	if h, _ := callbackPanicHandler.Load().(callbackPanic); h.handler != nil {
+		defer c.recover(a, result, h.handler)
+	}
+	values := c.values.Get().(*[]reflect.Value)
+	args := *values
+	c.readArgs(a.args, args)
+	ret := c.fn.Call(args)
 	for i := range args {
-		var pos int
-		switch fnType.In(i).Kind() {
-		case reflect.Float32, reflect.Float64:
-			if floatsN >= numOfFloatRegisters {
-				pos = stack
-				stack++
-			} else {
-				pos = floatsN
-			}
-			floatsN++
-		case reflect.Struct:
-			// This is the CDecl field
-			args[i] = reflect.Zero(fnType.In(i))
-			continue
-		default:
-
-			if intsN >= numOfIntegerRegisters() {
-				pos = stack
-				stack++
-			} else {
-				// the integers begin after the floats in frame
-				pos = intsN + numOfFloatRegisters
-			}
-			intsN++
-		}
-		args[i] = reflect.NewAt(fnType.In(i), unsafe.Pointer(&frame[pos])).Elem()
+		args[i] = reflect.Value{}
 	}
-	ret := fn.Call(args)
+	c.values.Put(values)
 	if len(ret) > 0 {
-		switch k := ret[0].Kind(); k {
-		case reflect.Uint, reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8, reflect.Uintptr:
-			a.result = uintptr(ret[0].Uint())
-		case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8:
-			a.result = uintptr(ret[0].Int())
-		case reflect.Bool:
-			if ret[0].Bool() {
-				a.result = 1
+		writeCallbackResult(a, result, ret[0])
+	}
+}
+
+// writeCallbackResult stores v in the result registers of a or in the memory at result.
+func writeCallbackResult(a *callbackArgs, result unsafe.Pointer, v reflect.Value) {
+	switch k := v.Kind(); k {
+	case reflect.Uint, reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8, reflect.Uintptr:
+		a.result = uintptr(v.Uint())
+	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8:
+		a.result = uintptr(v.Int())
+	case reflect.Bool:
+		if v.Bool() {
+			a.result = 1
+		} else {
+			a.result = 0
+		}
+	case reflect.Pointer:
+		a.result = v.Pointer()
+	case reflect.UnsafePointer:
+		a.result = v.Pointer()
+	case reflect.String:
+		a.result = cString(v.String())
+	case reflect.Float32:
+		a.fresult[0] = uintptr(math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		a.fresult[0] = uintptr(math.Float64bits(v.Float()))
+	case reflect.Struct, reflect.Complex64, reflect.Complex128:
+		if k != reflect.Struct {
+			v = complexToStruct(v)
+		}
+		if result != nil {
+			reflect.NewAt(v.Type(), result).Elem().Set(v)
+			break
+		}
+		var ints, floats int
+		callbackStructResult(v, func(x uintptr) {
+			if ints == 0 {
+				a.result = x
 			} else {
-				a.result = 0
+				a.result2 = x
 			}
-		case reflect.Pointer:
-			a.result = ret[0].Pointer()
-		case reflect.UnsafePointer:
-			a.result = ret[0].Pointer()
-		default:
-			panic("purego: unsupported kind: " + k.String())
+			ints++
+		}, func(x uintptr) {
+			a.fresult[floats] = x
+			floats++
+		})
+	default:
+		panic("purego: unsupported kind: " + k.String())
+	}
+}
+
+// cString copies s into memory allocated with malloc from libc and returns the
+// NUL-terminated string which the C caller frees with free.
+func cString(s string) uintptr {
+	if mallocAddr == 0 {
+		panic("purego: malloc is required to return strings from callbacks but wasn't found")
+	}
+	p, _, _ := syscall_syscallN(mallocAddr, []uintptr{uintptr(len(s) + 1)})
+	if p == 0 {
+		panic("purego: out of memory for the string result of a callback")
+	}
+	b := unsafe.Slice((*byte)(*(*unsafe.Pointer)(unsafe.Pointer(&p))), len(s)+1)
+	copy(b, s)
+	b[len(s)] = 0
+	return p
+}
+
+// recover is deferred by callbackWrap when a panic handler is set. If reading the arguments,
+// calling the Go function or writing its result panicked, it returns the result of handler instead.
+func (c *callback) recover(a *callbackArgs, result unsafe.Pointer, handler func(cb uintptr, v any) any) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	v := handler(c.addr, r)
+	if c.fn.Type().NumOut() == 0 {
+		return
+	}
+	// a panic here would unwind through the C frames so anything that can't be converted
+	// or written is zero
+	defer func() {
+		if recover() != nil {
+			clearCallbackResult(a, result)
 		}
+	}()
+	clearCallbackResult(a, result)
+	out := c.fn.Type().Out(0)
+	if rv := reflect.ValueOf(v); rv.IsValid() && rv.Type().ConvertibleTo(out) {
+		writeCallbackResult(a, result, rv.Convert(out))
+	} else {
+		writeCallbackResult(a, result, reflect.Zero(out))
+	}
+}
+
+// clearCallbackResult resets a result that was partly written before a panic.
+func clearCallbackResult(a *callbackArgs, result unsafe.Pointer) {
+	if result == nil {
+		a.result = 0
 	}
+	a.result2 = 0
+	a.fresult = [4]uintptr{}
 }
 
 // callbackasmAddr returns address of runtime.callbackasm
@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build darwin || freebsd || linux || netbsd
+
+package purego_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/ebitengine/purego"
+	"github.com/ebitengine/purego/internal/load"
+)
+
+func TestSyscallN_errno(t *testing.T) {
+	library, err := getSystemLibrary()
+	if err != nil {
+		t.Fatalf("couldn't get system library: %s", err)
+	}
+	libc, err := load.OpenLibrary(library)
+	if err != nil {
+		t.Fatalf("failed to dlopen: %s", err)
+	}
+	closeFn, err := load.OpenSymbol(libc, "close")
+	if err != nil {
+		t.Fatalf("failed to find close: %s", err)
+	}
+	badFd := -1
+	r1, _, errno := purego.SyscallN(closeFn, uintptr(badFd))
+	if int32(r1) != -1 {
+		t.Errorf("close(-1) returned %d wanted -1", int32(r1))
+	}
+	if syscall.Errno(errno) != syscall.EBADF {
+		t.Errorf("close(-1) errno got %v wanted %v", syscall.Errno(errno), syscall.EBADF)
+	}
+	getpid, err := load.OpenSymbol(libc, "getpid")
+	if err != nil {
+		t.Fatalf("failed to find getpid: %s", err)
+	}
+	if _, _, errno := purego.SyscallN(getpid); errno != 0 {
+		t.Errorf("getpid() errno got %v wanted 0", syscall.Errno(errno))
+	}
+}
+
+func TestRegisterFunc_errno(t *testing.T) {
+	library, err := getSystemLibrary()
+	if err != nil {
+		t.Fatalf("couldn't get system library: %s", err)
+	}
+	libc, err := load.OpenLibrary(library)
+	if err != nil {
+		t.Fatalf("failed to dlopen: %s", err)
+	}
+	{
+		var closeFn func(fd int32) (int32, syscall.Errno)
+		purego.RegisterLibFunc(&closeFn, libc, "close")
+		if ret, errno := closeFn(-1); ret != -1 || errno != syscall.EBADF {
+			t.Errorf("close(-1) got (%d, %v) wanted (-1, %v)", ret, errno, syscall.EBADF)
+		}
+	}
+	{
+		var closeFn func(fd int32) error
+		purego.RegisterLibFunc(&closeFn, libc, "close")
+		if err := closeFn(-1); !errors.Is(err, syscall.EBADF) {
+			t.Errorf("close(-1) got %v wanted %v", err, syscall.EBADF)
+		}
+		var getpid func() (int32, error)
+		purego.RegisterLibFunc(&getpid, libc, "getpid")
+		if _, err := getpid(); err != nil {
+			t.Errorf("getpid() got %v wanted nil", err)
+		}
+	}
+	{
+		// struct arguments use the generic path instead of a call plan
+		type fd struct{ fd int32 }
+		if runtime.GOOS == "linux" && (runtime.GOARCH == "amd64" || runtime.GOARCH == "arm64" || runtime.GOARCH == "loong64") ||
+			runtime.GOOS == "darwin" && (runtime.GOARCH == "amd64" || runtime.GOARCH == "arm64") {
+			var closeFn func(fd) (int32, error)
+			purego.RegisterLibFunc(&closeFn, libc, "close")
+			if ret, err := closeFn(fd{-1}); ret != -1 || !errors.Is(err, syscall.EBADF) {
+				t.Errorf("close(fd{-1}) got (%d, %v) wanted (-1, %v)", ret, err, syscall.EBADF)
+			}
+		}
+	}
+	{
+		// errno must be read on the thread that made the call
+		var closeFn func(fd int32) (int32, syscall.Errno)
+		purego.RegisterLibFunc(&closeFn, libc, "close")
+		var getpid func() (int32, syscall.Errno)
+		purego.RegisterLibFunc(&getpid, libc, "getpid")
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					if i%2 == 0 {
+						if _, errno := closeFn(-1); errno != syscall.EBADF {
+							t.Errorf("close(-1) errno got %v wanted %v", errno, syscall.EBADF)
+							return
+						}
+					} else if _, errno := getpid(); errno != 0 {
+						t.Errorf("getpid() errno got %v wanted 0", errno)
+						return
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+	}
+}
+
+func TestRegisterFunc_variadic(t *testing.T) {
+	if runtime.GOARCH != "amd64" && runtime.GOARCH != "arm64" && runtime.GOARCH != "loong64" {
+		t.Skip("C variadic functions are not supported on " + runtime.GOARCH)
+	}
+	library, err := getSystemLibrary()
+	if err != nil {
+		t.Fatalf("couldn't get system library: %s", err)
+	}
+	libc, err := load.OpenLibrary(library)
+	if err != nil {
+		t.Fatalf("failed to dlopen: %s", err)
+	}
+	buf := make([]byte, 128)
+	cstring := func() string {
+		for i, b := range buf {
+			if b == 0 {
+				return string(buf[:i])
+			}
+		}
+		return string(buf)
+	}
+	{
+		var snprintf func(str []byte, size uintptr, format string, _ purego.Variadic, i int32, f float64, s string) int32
+		purego.RegisterLibFunc(&snprintf, libc, "snprintf")
+		const want = "42 1.50 hello"
+		if n := snprintf(buf, uintptr(len(buf)), "%d %.2f %s", purego.Variadic{}, 42, 1.5, "hello"); n != int32(len(want)) {
+			t.Errorf("snprintf returned %d wanted %d", n, len(want))
+		}
+		if got := cstring(); got != want {
+			t.Errorf("snprintf got %q wanted %q", got, want)
+		}
+	}
+	{
+		// float32 is promoted to double
+		var snprintf func(str []byte, size uintptr, format string, _ purego.Variadic, a, b float32) int32
+		purego.RegisterLibFunc(&snprintf, libc, "snprintf")
+		snprintf(buf, uintptr(len(buf)), "%.2f %.2f", purego.Variadic{}, 0.25, -2.5)
+		if got, want := cstring(), "0.25 -2.50"; got != want {
+			t.Errorf("snprintf got %q wanted %q", got, want)
+		}
+	}
+	{
+		// more arguments than there are registers
+		var snprintf func(str []byte, size uintptr, format string, _ purego.Variadic, args ...any) int32
+		purego.RegisterLibFunc(&snprintf, libc, "snprintf")
+		args := []any{1, 2.5, 3, float32(4.5), 5, 6.5, 7, 8.5, 9, 10.5, 11, 12.5}
+		snprintf(buf, uintptr(len(buf)), "%d %.1f %d %.1f %d %.1f %d %.1f %d %.1f %d %.1f", purego.Variadic{}, args...)
+		if got, want := cstring(), fmt.Sprintf("%d %.1f %d %.1f %d %.1f %d %.1f %d %.1f %d %.1f", args...); got != want {
+			t.Errorf("snprintf got %q wanted %q", got, want)
+		}
+	}
+	{
+		var fn func(_ purego.Variadic, _ purego.Variadic)
+		if err := purego.CheckSignature(reflect.TypeOf(fn)); err == nil {
+			t.Errorf("CheckSignature with two Variadic markers returned nil wanted an error")
+		}
+	}
+}
@@ -10,8 +10,11 @@ import (
 
 var syscall15XABI0 uintptr
 
-func syscall_syscall15X(fn, a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14, a15 uintptr) (r1, r2, err uintptr) {
-	r1, r2, errno := syscall.Syscall15(fn, 15, a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14, a15)
+// errnoLocation is zero because the error is reported by syscall.SyscallN on Windows.
+var errnoLocation uintptr
+
+func syscall_syscallN(fn uintptr, args []uintptr) (r1, r2, err uintptr) {
+	r1, r2, errno := syscall.SyscallN(fn, args...)
 	return r1, r2, uintptr(errno)
 }
 
@@ -41,6 +44,10 @@ func NewCallback(fn any) uintptr {
 	return syscall.NewCallback(fn)
 }
 
+// FreeCallback does nothing on Windows because the callbacks created by syscall.NewCallback
+// are never released.
+func FreeCallback(cb uintptr) {}
+
 func loadSymbol(handle uintptr, name string) (uintptr, error) {
 	return syscall.GetProcAddress(syscall.Handle(handle), name)
 }
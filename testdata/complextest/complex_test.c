@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+#include <complex.h>
+#include <stdint.h>
+
+float _Complex ComplexFloatMul(float _Complex a, float _Complex b) {
+    return a * b;
+}
+
+double _Complex ComplexDoubleMul(double _Complex a, double _Complex b) {
+    return a * b;
+}
+
+double ComplexMixed(int32_t a, float _Complex b, double c, double _Complex d) {
+    return a + crealf(b) + cimagf(b) + c + creal(d) + cimag(d);
+}
+
+// ComplexAfterDoubles uses all but one of the float registers before d
+double _Complex ComplexAfterDoubles(double a, double b, double c, double e, double f, double g, double h, double _Complex d) {
+    return d + (a + b + c + e + f + g + h);
+}
+
+// ComplexAfterFloats uses all but one of the float registers before d
+float _Complex ComplexAfterFloats(float a, float b, float c, float e, float f, float g, float h, float _Complex d) {
+    return d + (a + b + c + e + f + g + h);
+}
+
+int32_t CallComplexCallback(int32_t (*cb)(float _Complex, double _Complex), float _Complex a, double _Complex b) {
+    return cb(a, b);
+}
+
+int32_t CallComplexCallbackAfterDoubles(int32_t (*cb)(double, double, double, double, double, double, double, double _Complex, float _Complex),
+    double _Complex d, float _Complex f) {
+    return cb(1, 2, 3, 4, 5, 6, 7, d, f);
+}
@@ -1,6 +1,8 @@
 // SPDX-License-Identifier: Apache-2.0
 // SPDX-FileCopyrightText: 2023 The Ebitengine Authors
 
+#include <stdint.h>
+#include <stdlib.h>
 #include <string.h>
 
 typedef int (*callback)(const char *, int);
@@ -11,3 +13,53 @@ int callCallback(const void *fp, const char *s) {
     ((callback)(fp))(s, strlen(s));
     return sentinel;
 }
+
+double callDoubleCallback(double (*cb)(double, float), double a, float b) {
+    return cb(a, b);
+}
+
+float callFloatCallback(float (*cb)(float), float a) {
+    return cb(a);
+}
+
+typedef struct {
+    float x, y;
+} Vec2;
+
+Vec2 callVec2Callback(Vec2 (*cb)(Vec2, Vec2), Vec2 a, Vec2 b) {
+    return cb(a, b);
+}
+
+typedef struct {
+    int32_t id;
+    float weight;
+    int64_t count;
+} Mixed;
+
+Mixed callMixedCallback(Mixed (*cb)(int64_t, int64_t, int64_t, int64_t, int64_t, int64_t, Mixed), Mixed m) {
+    return cb(1, 2, 3, 4, 5, 6, m);
+}
+
+typedef struct {
+    int64_t a, b, c;
+} Big;
+
+Big callBigCallback(Big (*cb)(int64_t, Big), int64_t n, Big b) {
+    return cb(n, b);
+}
+
+static int add(int a, int b) {
+    return a + b;
+}
+
+typedef char *(*stringCallback)(const char *, int (*)(int, int));
+
+// callStringCallback copies the string cb returns into buf and frees it.
+int callStringCallback(stringCallback cb, const char *s, char *buf, size_t n) {
+    char *ret = cb(s, add);
+    strncpy(buf, ret, n - 1);
+    buf[n - 1] = '\0';
+    int len = (int)strlen(ret);
+    free(ret);
+    return len;
+}
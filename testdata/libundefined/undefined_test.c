@@ -0,0 +1,9 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+// purego_undefined is not defined by any library so loading this one with RTLD_NOW fails.
+extern int purego_undefined(void);
+
+int call_undefined(void) {
+    return purego_undefined();
+}
@@ -0,0 +1,7 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+// libmemfdbase.so is built with this name as its soname so libmemfddep.so finds it.
+int base_value(void) {
+    return 40;
+}
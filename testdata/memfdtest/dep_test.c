@@ -0,0 +1,9 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+// base_value is defined in libmemfdbase.so which libmemfddep.so is linked against.
+int base_value(void);
+
+int dep_value(void) {
+    return base_value() + 2;
+}
@@ -0,0 +1,9 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+// counter exists once per link-map namespace the library is loaded into.
+static int counter;
+
+int increment(void) {
+    return ++counter;
+}
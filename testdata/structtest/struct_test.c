@@ -374,3 +374,58 @@ struct FourInt32s {
 int32_t FourInt32s(struct FourInt32s s) {
     return s.f0 + s.f1 + s.f2 + s.f3;
 }
+
+struct TwoPointers {
+    long *x, *y;
+};
+
+long TwoPointers(struct TwoPointers p) {
+    return *p.x + *p.y;
+}
+
+struct PointerAndInt {
+    long *x;
+    int32_t n;
+};
+
+long PointerAndInt(struct PointerAndInt p) {
+    return *p.x * p.n;
+}
+
+struct Bools {
+    _Bool a, b, c;
+    int32_t d;
+};
+
+int32_t Bools(struct Bools b) {
+    return (b.a ? 1 : 0) + (b.b ? 2 : 0) + (b.c ? 4 : 0) + b.d;
+}
+
+struct Int32Int8Int64 {
+    int32_t a;
+    int8_t b;
+    int64_t c;
+};
+
+int64_t Int32Int8Int64(struct Int32Int8Int64 s) {
+    return s.a + s.b + s.c;
+}
+
+struct FiveInt32s {
+    int32_t a, b, c, d, e;
+};
+
+int32_t FiveInt32s(struct FiveInt32s s) {
+    return s.a + s.b + s.c + s.d + s.e;
+}
+
+struct LongsAndBool {
+    long a, b;
+    _Bool c;
+};
+
+long LongsAndBool(struct LongsAndBool s) {
+    if (!s.c)
+        return -1;
+    return s.a + s.b;
+}
@@ -236,3 +236,33 @@ struct Ptr1 ReturnPtr1(int64_t *a, void *b) {
     struct Ptr1 s = {a, b};
     return s;
 }
+
+struct Bools{
+     _Bool a, b;
+     int32_t c;
+};
+
+struct Bools ReturnBools(_Bool a, _Bool b, int32_t c) {
+    struct Bools s = {a, b, c};
+    return s;
+}
+
+struct PtrAndBool{
+     void *p;
+     _Bool ok;
+};
+
+struct PtrAndBool ReturnPtrAndBool(void *p, _Bool ok) {
+    struct PtrAndBool s = {p, ok};
+    return s;
+}
+
+struct LongsAndBool{
+     int64_t a, b;
+     _Bool c;
+};
+
+struct LongsAndBool ReturnLongsAndBool(int64_t a, int64_t b, _Bool c) {
+    struct LongsAndBool s = {a, b, c};
+    return s;
+}
@@ -0,0 +1,6 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+// the variables LibVar looks up with the GNU and the SysV hash table
+int purego_counter = 7;
+double purego_values[4] = {1, 2, 3, 4};
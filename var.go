@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build darwin || freebsd || linux || netbsd
+
+package purego
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Var is a variable of type T that a shared library exports, like environ,
+// optind or a version string. T must have the same layout as the C type.
+//
+//	optind, err := purego.LibVar[int32](libc, "optind")
+//	if err != nil {
+//		panic(err)
+//	}
+//	optind.Store(1)
+type Var[T any] struct {
+	ptr *T
+}
+
+// NewVar returns the Var of type T at addr, for example an address returned by Dlsym.
+func NewVar[T any](addr uintptr) Var[T] {
+	return Var[T]{ptr: *(*(*T))(unsafe.Pointer(&addr))}
+}
+
+// LibVar looks up the variable name in the library handle with Dlsym. On Linux for amd64,
+// arm64 and loong64 it looks up the size of the symbol in the dynamic symbol table of the
+// library and returns an error if it is unknown or not the size of T. Other platforms don't
+// check the size.
+func LibVar[T any](handle uintptr, name string) (Var[T], error) {
+	addr, err := Dlsym(handle, name)
+	if err != nil {
+		return Var[T]{}, err
+	}
+	size, err := symbolSize(addr, name)
+	if err != nil {
+		return Var[T]{}, err
+	}
+	var zero T
+	if size != 0 && size != unsafe.Sizeof(zero) {
+		return Var[T]{}, fmt.Errorf("purego: %s has %d bytes but %T has %d", name, size, zero, unsafe.Sizeof(zero))
+	}
+	return NewVar[T](addr), nil
+}
+
+// Load returns the value of the variable.
+func (v Var[T]) Load() T {
+	return *v.ptr
+}
+
+// Store sets the variable to x.
+func (v Var[T]) Store(x T) {
+	*v.ptr = x
+}
+
+// Ptr returns a pointer to the variable. It points into the memory of the library and is
+// only valid while the library is loaded.
+func (v Var[T]) Ptr() *T {
+	return v.ptr
+}
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2026 The Ebitengine Authors
+
+//go:build darwin || freebsd || (linux && !android) || netbsd
+
+package purego_test
+
+import (
+	"runtime"
+	"testing"
+	"unsafe"
+
+	"github.com/ebitengine/purego"
+)
+
+func TestLibVar(t *testing.T) {
+	library, err := getSystemLibrary()
+	if err != nil {
+		t.Fatalf("couldn't get system library: %s", err)
+	}
+	libc, err := purego.Dlopen(library, purego.RTLD_NOW|purego.RTLD_GLOBAL)
+	if err != nil {
+		t.Fatalf("Dlopen(%q) failed: %v", library, err)
+	}
+
+	optind, err := purego.LibVar[int32](libc, "optind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := optind.Load()
+	defer optind.Store(old)
+	optind.Store(7)
+	addr, err := purego.Dlsym(libc, "optind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := *optind.Ptr(); got != 7 || uintptr(unsafe.Pointer(optind.Ptr())) != addr {
+		t.Errorf("optind is %d at %p wanted 7 at %#x", got, optind.Ptr(), addr)
+	}
+	if got := purego.NewVar[int32](addr).Load(); got != 7 {
+		t.Errorf("NewVar(%#x).Load() returned %d wanted 7", addr, got)
+	}
+
+	if _, err := purego.LibVar[int32](libc, "purego_no_such_variable"); err == nil {
+		t.Errorf("LibVar of a missing symbol succeeded")
+	}
+	if runtime.GOOS == "linux" && (runtime.GOARCH == "amd64" || runtime.GOARCH == "arm64" || runtime.GOARCH == "loong64") {
+		if _, err := purego.LibVar[int64](libc, "optind"); err == nil {
+			t.Errorf("LibVar[int64] of the 4 byte optind succeeded")
+		}
+	}
+}